@@ -0,0 +1,299 @@
+// Package client is a Go client for the SWIFT codes API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithAPIKey("..."))
+//	details, err := c.GetSwiftCode(ctx, "AAISALTRXXX")
+//	if errors.Is(err, client.ErrNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// Bank is the entry type of the API, the same type the server uses
+type Bank = model.Bank
+
+// BankDetails is a single SWIFT code, headquarters come with their branches
+type BankDetails struct {
+	Bank
+	Branches []Bank `json:"branches,omitempty"`
+
+	// ETag of the entry, pass it to Update or DeleteIfMatch to detect concurrent edits
+	ETag string `json:"-"`
+}
+
+// Country lists all SWIFT codes of a country
+type Country struct {
+	CountryISO2 string `json:"countryISO2"`
+	CountryName string `json:"countryName"`
+	SwiftCodes  []Bank `json:"swiftCodes"`
+}
+
+// LookupResult is the answer to a batch lookup, in request order
+type LookupResult struct {
+	SwiftCodes []Bank   `json:"swiftCodes"`
+	NotFound   []string `json:"notFound"`
+	Invalid    []string `json:"invalid"`
+}
+
+// Client talks to one SWIFT API server, it is safe for concurrent use
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	userAgent  string
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client (e.g. for custom transports)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTimeout limits each attempt, including reading the response body
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.httpClient.Timeout = d }
+}
+
+// WithAPIKey sends the key in the X-API-Key header, issued keys get their own rate limits
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithRetries retries rate limited requests, and network errors and 502/503/504
+// responses of requests that are safe to repeat, up to max times. The wait starts
+// at backoff and doubles after each attempt; Retry-After is honoured when sent.
+func WithRetries(max int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = max
+		c.backoff = backoff
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "swift_api-go-client",
+		maxRetries: 2,
+		backoff:    200 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// GetSwiftCode returns a single entry, ErrNotFound if the code is unknown
+func (c *Client) GetSwiftCode(ctx context.Context, swiftCode string) (*BankDetails, error) {
+	var details BankDetails
+	resp, err := c.do(ctx, http.MethodGet, "/v1/swift-codes/"+url.PathEscape(swiftCode), nil, nil, &details)
+	if err != nil {
+		return nil, err
+	}
+	details.ETag = resp.Header.Get("ETag")
+	return &details, nil
+}
+
+// ListCountry returns all SWIFT codes of a country, ErrNotFound if there are none
+func (c *Client) ListCountry(ctx context.Context, countryISO2 string) (*Country, error) {
+	var country Country
+	_, err := c.do(ctx, http.MethodGet, "/v1/swift-codes/country/"+url.PathEscape(countryISO2), nil, nil, &country)
+	if err != nil {
+		return nil, err
+	}
+	return &country, nil
+}
+
+// Lookup resolves many BIC8/BIC11 codes in one request (at most 5000)
+func (c *Client) Lookup(ctx context.Context, swiftCodes []string) (*LookupResult, error) {
+	var result LookupResult
+	body := map[string][]string{"swiftCodes": swiftCodes}
+	_, err := c.do(ctx, http.MethodPost, "/v1/swift-codes/lookup", nil, body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Add creates a new entry, ErrConflict if the SWIFT code already exists
+func (c *Client) Add(ctx context.Context, bank Bank) error {
+	_, err := c.do(ctx, http.MethodPost, "/v1/swift-codes", nil, bank, nil)
+	return err
+}
+
+// Update replaces an entry and returns its new ETag. With a non-empty etag the
+// update fails with ErrConflict if the entry has been changed since.
+func (c *Client) Update(ctx context.Context, bank Bank, etag string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPut, "/v1/swift-codes/"+url.PathEscape(bank.SwiftCode), ifMatch(etag), bank, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// Delete removes an entry, ErrNotFound if it does not exist
+func (c *Client) Delete(ctx context.Context, swiftCode string) error {
+	return c.DeleteIfMatch(ctx, swiftCode, "")
+}
+
+// DeleteIfMatch removes an entry unless it has changed since etag was read (ErrConflict)
+func (c *Client) DeleteIfMatch(ctx context.Context, swiftCode, etag string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/v1/swift-codes/"+url.PathEscape(swiftCode), ifMatch(etag), nil, nil)
+	return err
+}
+
+func ifMatch(etag string) http.Header {
+	if etag == "" {
+		return nil
+	}
+	return http.Header{"If-Match": []string{etag}}
+}
+
+// sends the request with retries and decodes a 2xx JSON body into out
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, in, out any) (*http.Response, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the batch lookup only reads, repeating it is harmless
+	idempotent := method != http.MethodPost || path == "/v1/swift-codes/lookup"
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, header, body)
+
+		var wait time.Duration
+		retry := attempt < c.maxRetries
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			retry = retry && idempotent
+		case resp.StatusCode == http.StatusTooManyRequests:
+			wait = retryAfter(resp)
+		case resp.StatusCode == http.StatusBadGateway,
+			resp.StatusCode == http.StatusServiceUnavailable,
+			resp.StatusCode == http.StatusGatewayTimeout:
+			retry = retry && idempotent
+			wait = retryAfter(resp)
+		default:
+			retry = false
+		}
+
+		if !retry {
+			if err != nil {
+				return nil, err
+			}
+			return resp, decode(resp, out)
+		}
+		if wait > c.maxBackoff {
+			// e.g. the daily quota is used up, waiting makes no sense
+			return resp, decode(resp, out)
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if wait == 0 {
+			wait = c.backoffFor(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, header http.Header, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+// exponential backoff with up to 50% jitter
+func (c *Client) backoffFor(attempt int) time.Duration {
+	d := c.backoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func decode(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return newError(resp)
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
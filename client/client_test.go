@@ -0,0 +1,162 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/client"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/handler"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/openapi"
+)
+
+var testServer *httptest.Server
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	db := config.ConnectToDB()
+	config.SetDB(db)
+	config.InitSchema(db)
+
+	db.Exec("DELETE FROM banks WHERE swift_code LIKE 'CLNT%'")
+	for _, bank := range []model.Bank{
+		{Address: "Client Street 1", Name: "Client Bank", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true, SwiftCode: "CLNTPLPWXXX"},
+		{Address: "Client Street 2", Name: "Client Bank Branch", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: false, SwiftCode: "CLNTPLPW001"},
+	} {
+		database.InsertBank(db, bank)
+	}
+
+	// the same routes and validation as the server
+	validate, err := openapi.Validator()
+	if err != nil {
+		panic(err)
+	}
+	router := gin.New()
+	if err := handler.RegisterRoutes(router, handler.RouteOptions{Validate: validate}); err != nil {
+		panic(err)
+	}
+	testServer = httptest.NewServer(router)
+
+	code := m.Run()
+
+	testServer.Close()
+	db.Exec("DELETE FROM banks WHERE swift_code LIKE 'CLNT%'")
+	db.Close()
+
+	os.Exit(code)
+}
+
+func newClient(t *testing.T) *client.Client {
+	c, err := client.New(testServer.URL, client.WithTimeout(5*time.Second))
+	assert.NoError(t, err)
+	return c
+}
+
+func TestClient_GetSwiftCode(t *testing.T) {
+	c := newClient(t)
+	ctx := context.Background()
+
+	details, err := c.GetSwiftCode(ctx, "CLNTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "Client Bank", details.Name)
+	assert.True(t, details.IsHeadquarter)
+	assert.NotEmpty(t, details.ETag)
+	if assert.Len(t, details.Branches, 1) {
+		assert.Equal(t, "CLNTPLPW001", details.Branches[0].SwiftCode)
+	}
+
+	_, err = c.GetSwiftCode(ctx, "CLNTPLPW999")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	_, err = c.GetSwiftCode(ctx, "CLNT")
+	assert.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestClient_ListCountryAndLookup(t *testing.T) {
+	c := newClient(t)
+	ctx := context.Background()
+
+	country, err := c.ListCountry(ctx, "PL")
+	assert.NoError(t, err)
+	assert.Equal(t, "POLAND", country.CountryName)
+	assert.NotEmpty(t, country.SwiftCodes)
+
+	result, err := c.Lookup(ctx, []string{"CLNTPLPW", "CLNTPLPW001", "CLNTPLPW999", "bad"})
+	assert.NoError(t, err)
+	assert.Len(t, result.SwiftCodes, 2)
+	assert.Equal(t, []string{"CLNTPLPW999"}, result.NotFound)
+	assert.Equal(t, []string{"bad"}, result.Invalid)
+}
+
+func TestClient_AddUpdateDelete(t *testing.T) {
+	c := newClient(t)
+	ctx := context.Background()
+
+	bank := client.Bank{
+		Address:       "Client Street 3",
+		Name:          "Client Bank Branch 2",
+		CountryCode:   "PL",
+		CountryName:   "POLAND",
+		IsHeadquarter: false,
+		SwiftCode:     "CLNTPLPW002",
+	}
+	assert.NoError(t, c.Add(ctx, bank))
+	assert.ErrorIs(t, c.Add(ctx, bank), client.ErrConflict, "Adding twice should conflict")
+
+	bad := bank
+	bad.SwiftCode = "CLNTPLPW003"
+	bad.CountryCode = "POL"
+	assert.ErrorIs(t, c.Add(ctx, bad), client.ErrValidation)
+
+	details, err := c.GetSwiftCode(ctx, bank.SwiftCode)
+	assert.NoError(t, err)
+
+	bank.Address = "Client Street 4"
+	etag, err := c.Update(ctx, bank, details.ETag)
+	assert.NoError(t, err)
+	assert.NotEqual(t, details.ETag, etag)
+
+	// the old ETag is stale now
+	_, err = c.Update(ctx, bank, details.ETag)
+	assert.ErrorIs(t, err, client.ErrConflict)
+	assert.ErrorIs(t, c.DeleteIfMatch(ctx, bank.SwiftCode, details.ETag), client.ErrConflict)
+
+	assert.NoError(t, c.DeleteIfMatch(ctx, bank.SwiftCode, etag))
+	assert.ErrorIs(t, c.Delete(ctx, bank.SwiftCode), client.ErrNotFound)
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		testServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer limited.Close()
+
+	c, err := client.New(limited.URL, client.WithRetries(2, time.Millisecond))
+	assert.NoError(t, err)
+	_, err = c.GetSwiftCode(context.Background(), "CLNTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// out of retries
+	calls.Store(0)
+	c, err = client.New(limited.URL, client.WithRetries(1, time.Millisecond))
+	assert.NoError(t, err)
+	_, err = c.GetSwiftCode(context.Background(), "CLNTPLPWXXX")
+	assert.ErrorIs(t, err, client.ErrRateLimited)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// match them with errors.Is
+var (
+	ErrNotFound    = errors.New("not found")           // 404
+	ErrValidation  = errors.New("invalid request")     // 400, 413
+	ErrConflict    = errors.New("conflict")            // 409 already exists, 412 changed since the ETag
+	ErrRateLimited = errors.New("rate limit exceeded") // 429 after all retries
+)
+
+// Error is a non-2xx response of the API
+type Error struct {
+	StatusCode int
+	Message    string // message (or error) field of the response body
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("swift api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("swift api: %d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap maps the status code to one of the Err* values
+func (e *Error) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return ErrValidation
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func newError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+
+	// routes answer with either {"message": ...} or {"error": ...}
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil {
+		e.Message = body.Message
+		if e.Message == "" {
+			e.Message = body.Error
+		}
+	}
+	return e
+}
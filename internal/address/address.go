@@ -0,0 +1,208 @@
+package address
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// rule describes how addresses of one country are written
+type rule struct {
+	postCode     *regexp.Regexp // the post code at the end of the address, nil when it is not recognised
+	townPostCode *regexp.Regexp // the post code before the town in the last part (..., 60325 FRANKFURT)
+	numberFirst  bool           // the building number comes before the street (12 RUE ...)
+}
+
+func newRule(postCode string, numberFirst bool) rule {
+	return rule{
+		postCode:     regexp.MustCompile(`(?i)(?:^|[\s,])(` + postCode + `)$`),
+		townPostCode: regexp.MustCompile(`(?i),\s*(` + postCode + `)\s+[^,\d]+$`),
+		numberFirst:  numberFirst,
+	}
+}
+
+// countries without a rule are parsed with the building number after the street and without post codes
+var rules = map[string]rule{
+	"AL": newRule(`\d{4}`, false),
+	"AT": newRule(`\d{4}`, false),
+	"AW": {},
+	"BE": newRule(`\d{4}`, false),
+	"BG": newRule(`\d{4}`, false),
+	"CH": newRule(`\d{4}`, false),
+	"CL": newRule(`\d{7}`, false),
+	"CZ": newRule(`\d{3} ?\d{2}`, false),
+	"DE": newRule(`\d{5}`, false),
+	"DK": newRule(`\d{4}`, false),
+	"EE": newRule(`\d{5}`, false),
+	"ES": newRule(`\d{5}`, false),
+	"FR": newRule(`\d{5}`, true),
+	"GB": newRule(`[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}`, true),
+	"IE": newRule(`[A-Z]\d[\dW] ?[A-Z\d]{4}`, true),
+	"IT": newRule(`\d{5}`, false),
+	"LT": newRule(`LT-\d{5}`, false),
+	"LU": newRule(`L-\d{4}`, true),
+	"LV": newRule(`LV-\d{4}`, false),
+	"MC": newRule(`980\d{2}`, true),
+	"MT": newRule(`[A-Z]{3} ?\d{4}`, true),
+	"NL": newRule(`\d{4} ?[A-Z]{2}`, false),
+	"PL": newRule(`\d{2}-\d{3}`, false),
+	"PT": newRule(`\d{4}-\d{3}`, false),
+	"SE": newRule(`\d{3} ?\d{2}`, false),
+	"SK": newRule(`\d{3} ?\d{2}`, false),
+	"US": newRule(`\d{5}(?:-\d{4})?`, true),
+	"UY": newRule(`\d{5}`, false),
+}
+
+// a building number: 5, 42C, 20A-1, 15BIS/17, 20/22 - 5
+const number = `\d+(?:BIS|TER|[A-Z])?(?:\s?[/-]\s?\d+[A-Z]?)*`
+
+var (
+	// floors and offices are not part of the street or the building number, ordinal
+	// floors (6TH, 6-TH) are removed with their suffix
+	unit = regexp.MustCompile(`(?i)(?:^|\s)(?:FLOOR|FL\.|OF\.?|OFFICE|OFICINA|APT\.?|SUITE)\s*\d+(?:-?(?:ST|ND|RD|TH)|[A-Z])?\b`)
+	// 12 RUE GRIMALDI, maybe after a building name
+	numberStreet = regexp.MustCompile(`(?i)(?:^|\s)(` + number + `)\s+(\D+)$`)
+	// UL. WRONIA 31, maybe followed by a building name
+	streetNumber = regexp.MustCompile(`(?i)^(.*?)\s(` + number + `(?:\s[A-Z])?)(?:\s\D*)?$`)
+	// a street has at least one word, not only an abbreviation like H.
+	word = regexp.MustCompile(`(?i)[A-Z]{3,}`)
+)
+
+// Parse splits a free-text address of the directory, e.g.
+// "HYRJA 3 RR. DRITAN HOXHA ND. 11 TIRANA, TIRANA, 1023", into its parts. The
+// parts are read from the end: the post code in the format of the country, the
+// town (the given town when it is known, otherwise the last part after a comma),
+// the country subdivision following the town and the street with its building
+// number before it. Returns nil when no part was recognised.
+func Parse(countryCode, text, town string) *model.PostalAddress {
+	r := rules[strings.ToUpper(countryCode)]
+	text = strings.Trim(strings.Join(strings.Fields(text), " "), " ,-")
+
+	var a model.PostalAddress
+	if r.postCode != nil {
+		if m := r.postCode.FindStringSubmatchIndex(text); m != nil && strings.Contains(text[:m[2]], ",") {
+			a.PostCode = strings.ToUpper(text[m[2]:m[3]])
+			text = strings.TrimRight(text[:m[2]], " ,-")
+		} else if m := r.townPostCode.FindStringSubmatchIndex(text); m != nil {
+			a.PostCode = strings.ToUpper(text[m[2]:m[3]])
+			text = text[:m[2]] + strings.TrimSpace(text[m[3]:])
+		}
+	}
+
+	street := text
+	if before, found, after, ok := splitTown(text, town); ok {
+		street, a.TownName, a.CountrySubDivision = before, found, after
+	} else if town = strings.TrimSpace(town); town != "" {
+		a.TownName = town
+	} else if i := strings.LastIndex(text, ","); i >= 0 && !strings.ContainsFunc(text[i:], unicode.IsDigit) {
+		street, a.TownName = strings.TrimRight(text[:i], " ,-"), strings.TrimSpace(text[i+1:])
+	}
+
+	a.StreetName, a.BuildingNumber = splitStreet(street, r.numberFirst)
+	if a == (model.PostalAddress{}) {
+		return nil
+	}
+	return &a
+}
+
+// finds the town in the address and returns the text before and after it. The town
+// follows the last building number, there the first occurrence followed by a comma
+// is taken, otherwise the first one. A town inside the street (no occurrence after
+// the number) is taken from the end in the same way.
+func splitTown(text, town string) (before, found, after string, ok bool) {
+	words := strings.Fields(text)
+	townWords := strings.Fields(town)
+	if len(townWords) == 0 {
+		return "", "", "", false
+	}
+
+	// words up to the last one with a digit belong to the street
+	start := 0
+	for i, w := range words {
+		if strings.ContainsFunc(w, unicode.IsDigit) {
+			start = i + 1
+		}
+	}
+
+	var afterNumber, inStreet []int
+	for i := 0; i+len(townWords) <= len(words); i++ {
+		if !matchWords(words[i:i+len(townWords)], townWords) {
+			continue
+		}
+		if i >= start {
+			afterNumber = append(afterNumber, i)
+		} else {
+			inStreet = append(inStreet, i)
+		}
+	}
+	followedByComma := func(i int) bool { return strings.HasSuffix(words[i+len(townWords)-1], ",") }
+
+	at := -1
+	switch {
+	case len(afterNumber) > 0:
+		at = afterNumber[0]
+		if i := slices.IndexFunc(afterNumber, followedByComma); i >= 0 {
+			at = afterNumber[i]
+		}
+	case len(inStreet) > 0:
+		at = inStreet[len(inStreet)-1]
+		for _, i := range slices.Backward(inStreet) {
+			if followedByComma(i) {
+				at = i
+				break
+			}
+		}
+	default:
+		return "", "", "", false
+	}
+
+	end := at + len(townWords)
+	before = strings.Trim(strings.Join(words[:at], " "), " ,-")
+	found = strings.Trim(strings.Join(words[at:end], " "), " ,-")
+	after = strings.Trim(strings.Join(words[end:], " "), " ,-")
+	return before, found, after, true
+}
+
+func matchWords(words, town []string) bool {
+	for i := range town {
+		if !strings.EqualFold(strings.TrimRight(words[i], ","), town[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// splits the street line into the street name and the building number, the first
+// comma separated part with a building number is taken. Without a building number
+// the street is the last part when the number comes first, otherwise the first part.
+func splitStreet(line string, numberFirst bool) (street, building string) {
+	line = unit.ReplaceAllString(line, "")
+	var parts []string
+	for _, p := range strings.Split(line, ",") {
+		if p = strings.Trim(strings.Join(strings.Fields(p), " "), " -"); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return "", ""
+	}
+
+	for _, p := range parts {
+		if numberFirst {
+			if m := numberStreet.FindStringSubmatch(p); m != nil && word.MatchString(m[2]) {
+				return strings.Trim(m[2], " -"), m[1]
+			}
+			continue
+		}
+		if m := streetNumber.FindStringSubmatch(p); m != nil && word.MatchString(m[1]) {
+			return strings.Trim(m[1], " -"), m[2]
+		}
+	}
+	if numberFirst {
+		return parts[len(parts)-1], ""
+	}
+	return parts[0], ""
+}
@@ -0,0 +1,72 @@
+package address_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/address"
+	"github.com/white67/swift_api/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		country, text, town string
+		want                model.PostalAddress
+	}{
+		{"AL", "HYRJA 3 RR. DRITAN HOXHA ND. 11 TIRANA, TIRANA, 1023", "TIRANA",
+			model.PostalAddress{StreetName: "HYRJA 3 RR. DRITAN HOXHA ND.", BuildingNumber: "11", PostCode: "1023", TownName: "TIRANA", CountrySubDivision: "TIRANA"}},
+		{"PL", "STRZEGOMSKA 42C  WROCLAW, DOLNOSLASKIE, 53-611", "WROCLAW",
+			model.PostalAddress{StreetName: "STRZEGOMSKA", BuildingNumber: "42C", PostCode: "53-611", TownName: "WROCLAW", CountrySubDivision: "DOLNOSLASKIE"}},
+		{"PL", "DUBOIS STREET 5 A  WARSZAWA, MAZOWIECKIE, 00-184", "WARSZAWA",
+			model.PostalAddress{StreetName: "DUBOIS STREET", BuildingNumber: "5 A", PostCode: "00-184", TownName: "WARSZAWA", CountrySubDivision: "MAZOWIECKIE"}},
+		// the town is matched with more than one word
+		{"PL", "STOJALOWSKIEGO 7  BIELSKO BIALA, SLASKIE, 43-300", "BIELSKO BIALA",
+			model.PostalAddress{StreetName: "STOJALOWSKIEGO", BuildingNumber: "7", PostCode: "43-300", TownName: "BIELSKO BIALA", CountrySubDivision: "SLASKIE"}},
+		{"BG", "TODOR ALEKSANDROV BLVD 73 FLOOR 1 SOFIA, SOFIA, 1303", "SOFIA",
+			model.PostalAddress{StreetName: "TODOR ALEKSANDROV BLVD", BuildingNumber: "73", PostCode: "1303", TownName: "SOFIA", CountrySubDivision: "SOFIA"}},
+		// an ordinal floor is removed with its suffix
+		{"BG", "TSARIGRADSKO SHOSSE 115A FLOOR 6-TH SOFIA, SOFIA, 1784", "SOFIA",
+			model.PostalAddress{StreetName: "TSARIGRADSKO SHOSSE", BuildingNumber: "115A", PostCode: "1784", TownName: "SOFIA", CountrySubDivision: "SOFIA"}},
+		{"LV", "KR. BARONA STREET 20/22 - 5  REZEKNE, REZEKNE, LV-4601", "REZEKNE",
+			model.PostalAddress{StreetName: "KR. BARONA STREET", BuildingNumber: "20/22 - 5", PostCode: "LV-4601", TownName: "REZEKNE", CountrySubDivision: "REZEKNE"}},
+		// the building number comes first
+		{"MC", "LE BELLE EPOQUE 15BIS/17 AVENUE D'OSTENDE MONACO, MONACO, 98000", "MONACO",
+			model.PostalAddress{StreetName: "AVENUE D'OSTENDE", BuildingNumber: "15BIS/17", PostCode: "98000", TownName: "MONACO", CountrySubDivision: "MONACO"}},
+		{"MT", "FLOOR 1 58 MERCHANTS STREET VALLETTA, VALLETTA, VLT 1173", "VALLETTA",
+			model.PostalAddress{StreetName: "MERCHANTS STREET", BuildingNumber: "58", PostCode: "VLT 1173", TownName: "VALLETTA", CountrySubDivision: "VALLETTA"}},
+		// the town also ends the subdivision
+		{"CL", "AVENIDA ISIDORA GOYENECHEA 2800 FLOOR 23 LAS CONDES - SANTIAGO PROVINCIA DE SANTIAGO, 8320000", "SANTIAGO",
+			model.PostalAddress{StreetName: "AVENIDA ISIDORA GOYENECHEA", BuildingNumber: "2800", PostCode: "8320000", TownName: "SANTIAGO", CountrySubDivision: "PROVINCIA DE SANTIAGO"}},
+		// the post code follows the subdivision without a comma
+		{"CL", "ALCANTARA 200, OF. 202 LAS CONDES SANTIAGO, PROVINCIA DE SANTIAGO 8320000", "SANTIAGO",
+			model.PostalAddress{StreetName: "ALCANTARA", BuildingNumber: "200", PostCode: "8320000", TownName: "SANTIAGO", CountrySubDivision: "PROVINCIA DE SANTIAGO"}},
+		{"CL", "21 DE MAYO 330  ARICA, PROVINCIA DE ARICA, 1000000", "ARICA",
+			model.PostalAddress{StreetName: "21 DE MAYO", BuildingNumber: "330", PostCode: "1000000", TownName: "ARICA", CountrySubDivision: "PROVINCIA DE ARICA"}},
+		// no commas and no post code
+		{"AW", "WILHELMINASTRAAT 36  - ORANJESTAD ORANJESTAD-WEST AND ORANJESTAD-EAST ", "ORANJESTAD",
+			model.PostalAddress{StreetName: "WILHELMINASTRAAT", BuildingNumber: "36", TownName: "ORANJESTAD", CountrySubDivision: "ORANJESTAD-WEST AND ORANJESTAD-EAST"}},
+		{"PL", "  WARSZAWA, MAZOWIECKIE", "WARSZAWA",
+			model.PostalAddress{TownName: "WARSZAWA", CountrySubDivision: "MAZOWIECKIE"}},
+		// without a town it is the last part after a comma, here after the post code
+		{"DE", "Taunusanlage 12, 60325 Frankfurt am Main", "",
+			model.PostalAddress{StreetName: "Taunusanlage", BuildingNumber: "12", PostCode: "60325", TownName: "Frankfurt am Main"}},
+		{"US", "200 Park Avenue, New York, 10166", "",
+			model.PostalAddress{StreetName: "Park Avenue", BuildingNumber: "200", PostCode: "10166", TownName: "New York"}},
+		{"PL", "ul. Marszałkowska 1, Warszawa", "",
+			model.PostalAddress{StreetName: "ul. Marszałkowska", BuildingNumber: "1", TownName: "Warszawa"}},
+		// a number without a comma before it is not a post code
+		{"BG", "SLAVYANSKA STR 2000", "",
+			model.PostalAddress{StreetName: "SLAVYANSKA STR", BuildingNumber: "2000"}},
+		// a town that is not in the address is kept
+		{"UY", "PLAZA INDEPENDENCIA 743", "MONTEVIDEO",
+			model.PostalAddress{StreetName: "PLAZA INDEPENDENCIA", BuildingNumber: "743", TownName: "MONTEVIDEO"}},
+	}
+	for _, tt := range tests {
+		got := address.Parse(tt.country, tt.text, tt.town)
+		if assert.NotNil(t, got, tt.text) {
+			assert.Equal(t, tt.want, *got, tt.text)
+		}
+	}
+
+	assert.Nil(t, address.Parse("CL", "  ", ""))
+}
@@ -0,0 +1,109 @@
+// Package auth protects the admin routes (webhooks, screening decisions, /v1/admin)
+// with API keys sent as "Authorization: Bearer <key>". Keys also holds the API keys
+// issued to clients for their own rate limits.
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MinKeyLength is the shortest accepted key
+const MinKeyLength = 16
+
+const principalKey = "auth.principal"
+
+// Keys maps keys to the names of their holders, for admin keys the name is the
+// principal recorded for the requests made with the key
+type Keys struct {
+	names map[[32]byte]string // by SHA-256 of the key, lookups do not compare the key itself
+}
+
+// ParseKeys parses "name=key,name=key", an empty spec has no keys
+func ParseKeys(spec string) (*Keys, error) {
+	k := &Keys{names: make(map[[32]byte]string)}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(part, "=")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("key %q must be name=key", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("key name %q is used twice", name)
+		}
+		if len(key) < MinKeyLength {
+			return nil, fmt.Errorf("key of %q must have at least %d characters", name, MinKeyLength)
+		}
+		hash := sha256.Sum256([]byte(key))
+		if _, ok := k.names[hash]; ok {
+			return nil, fmt.Errorf("key of %q is used twice", name)
+		}
+		seen[name] = true
+		k.names[hash] = name
+	}
+	return k, nil
+}
+
+// Len returns the number of keys
+func (k *Keys) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.names)
+}
+
+// Lookup returns the name of the holder of key
+func (k *Keys) Lookup(key string) (string, bool) {
+	if k == nil || key == "" {
+		return "", false
+	}
+	name, ok := k.names[sha256.Sum256([]byte(key))]
+	return name, ok
+}
+
+var errNoKey = errors.New("missing bearer token")
+
+func bearer(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", errNoKey
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// Middleware rejects requests without a valid admin key with 401, without keys
+// (nil or empty) every request is rejected
+func Middleware(keys *Keys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := bearer(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Admin API key required"})
+			return
+		}
+		name, ok := keys.Lookup(token)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="admin", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid admin API key"})
+			return
+		}
+		c.Set(principalKey, name)
+		c.Next()
+	}
+}
+
+// Principal returns the name of the admin key holder, empty outside of Middleware
+func Principal(c *gin.Context) string {
+	return c.GetString(principalKey)
+}
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/auth"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := auth.ParseKeys(" alice = alice-key-0123456789 , bob=bob-key-0123456789,")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, keys.Len())
+
+	name, ok := keys.Lookup("alice-key-0123456789")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", name)
+	_, ok = keys.Lookup("alice")
+	assert.False(t, ok)
+
+	empty, err := auth.ParseKeys("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, empty.Len())
+
+	for _, spec := range []string{
+		"alice-key-0123456789",
+		"=alice-key-0123456789",
+		"alice=short",
+		"alice=alice-key-0123456789,alice=other-key-0123456789",
+		"alice=alice-key-0123456789,bob=alice-key-0123456789",
+	} {
+		_, err := auth.ParseKeys(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys, err := auth.ParseKeys("alice=alice-key-0123456789")
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", auth.Middleware(keys), func(c *gin.Context) {
+		c.String(http.StatusOK, auth.Principal(c))
+	})
+	router.GET("/disabled", auth.Middleware(nil), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name, path, header string
+		status             int
+		body               string
+	}{
+		{"valid key", "/admin", "Bearer alice-key-0123456789", http.StatusOK, "alice"},
+		{"scheme is case insensitive", "/admin", "bearer alice-key-0123456789", http.StatusOK, "alice"},
+		{"no header", "/admin", "", http.StatusUnauthorized, "Admin API key required"},
+		{"basic auth", "/admin", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized, "Admin API key required"},
+		{"wrong key", "/admin", "Bearer bob-key-0123456789", http.StatusUnauthorized, "Invalid admin API key"},
+		{"no keys configured", "/disabled", "Bearer alice-key-0123456789", http.StatusUnauthorized, "Invalid admin API key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.body)
+			if tt.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/cache"
+	"github.com/white67/swift_api/internal/model"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	c := cache.NewLRU[string](2, 0)
+
+	c.Set("A", "a")
+	c.Set("B", "b")
+
+	// touch A so B becomes the least recently used
+	_, ok := c.Get("A")
+	assert.True(t, ok)
+
+	c.Set("C", "c")
+
+	_, ok = c.Get("B")
+	assert.False(t, ok, "B should have been evicted")
+	_, ok = c.Get("A")
+	assert.True(t, ok)
+	_, ok = c.Get("C")
+	assert.True(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+	assert.InDelta(t, 0.75, stats.HitRatio, 0.001)
+}
+
+func TestLRU_TTL(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := cache.NewLRU[int](10, time.Minute)
+	c.SetClock(func() time.Time { return now })
+
+	c.Set("A", 1)
+
+	value, ok := c.Get("A")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("A")
+	assert.False(t, ok, "Entry should expire after TTL")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestDirectory_InvalidateBank(t *testing.T) {
+	d := cache.NewDirectory(10, 0)
+
+	d.Codes.Set("TESTPLPWXXX", "hq")
+	d.Codes.Set("TESTPLPW123", "branch")
+	d.Codes.Set("TESTPLPW456", "other branch")
+	d.Codes.Set("TESTDEPWXXX", "german hq")
+	d.Countries.Set("PL", "poland")
+	d.Countries.Set("DE", "germany")
+
+	// changing a branch invalidates the branch, its HQ and its country
+	d.InvalidateBank("TESTPLPW123", "PL")
+
+	_, ok := d.Codes.Get("TESTPLPW123")
+	assert.False(t, ok)
+	_, ok = d.Codes.Get("TESTPLPWXXX")
+	assert.False(t, ok, "Headquarter lists its branches and should be invalidated")
+	_, ok = d.Countries.Get("PL")
+	assert.False(t, ok)
+
+	// unrelated entries stay
+	_, ok = d.Codes.Get("TESTPLPW456")
+	assert.True(t, ok)
+	_, ok = d.Codes.Get("TESTDEPWXXX")
+	assert.True(t, ok)
+	_, ok = d.Countries.Get("DE")
+	assert.True(t, ok)
+
+	d.InvalidateBanks([]model.Bank{{SwiftCode: "TESTDEPWXXX", CountryCode: "DE"}})
+	_, ok = d.Codes.Get("TESTDEPWXXX")
+	assert.False(t, ok)
+	_, ok = d.Countries.Get("DE")
+	assert.False(t, ok)
+}
+
+func TestDirectory_Generation(t *testing.T) {
+	d := cache.NewDirectory(10, 0)
+
+	// a response loaded before an invalidation may be stale and is not stored
+	gen := d.Generation()
+	d.InvalidateBank("TESTPLPWXXX", "PL")
+	d.SetCode("TESTPLPWXXX", "stale hq", gen)
+	d.SetCountry("PL", "stale poland", gen)
+	_, ok := d.Codes.Get("TESTPLPWXXX")
+	assert.False(t, ok)
+	_, ok = d.Countries.Get("PL")
+	assert.False(t, ok)
+
+	gen = d.Generation()
+	d.SetCode("TESTPLPWXXX", "hq", gen)
+	d.SetCountry("PL", "poland", gen)
+	v, ok := d.Codes.Get("TESTPLPWXXX")
+	assert.True(t, ok)
+	assert.Equal(t, "hq", v)
+	_, ok = d.Countries.Get("PL")
+	assert.True(t, ok)
+
+	gen = d.Generation()
+	d.Purge()
+	d.SetCode("TESTPLPWXXX", "hq", gen)
+	_, ok = d.Codes.Get("TESTPLPWXXX")
+	assert.False(t, ok, "Purging invalidates too")
+}
+
+func TestDirectory_Nil(t *testing.T) {
+	var d *cache.Directory
+
+	// a disabled cache must be safe to invalidate and to store into
+	assert.NotPanics(t, func() {
+		d.InvalidateBank("TESTPLPWXXX", "PL")
+		d.Purge()
+		d.SetCode("TESTPLPWXXX", "hq", d.Generation())
+		d.SetCountry("PL", "poland", d.Generation())
+	})
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// Directory caches rendered lookup responses for single SWIFT codes and countries.
+// Entries are stored with SetCode and SetCountry, which drop responses loaded before
+// the latest invalidation.
+type Directory struct {
+	Codes     *LRU[any]
+	Countries *LRU[any]
+
+	// incremented by every invalidation, under mu so no entry is stored in between
+	mu         sync.Mutex
+	generation uint64
+}
+
+func NewDirectory(capacity int, ttl time.Duration) *Directory {
+	return &Directory{
+		Codes:     NewLRU[any](capacity, ttl),
+		Countries: NewLRU[any](capacity, ttl),
+	}
+}
+
+// Generation is read before a response is loaded from the database and passed on
+// to SetCode or SetCountry
+func (d *Directory) Generation() uint64 {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.generation
+}
+
+// SetCode stores the response of a code unless the directory was invalidated since
+// gen was read, the response may then hold data that was already changed
+func (d *Directory) SetCode(swiftCode string, response any, gen uint64) {
+	if d != nil {
+		d.set(d.Codes, swiftCode, response, gen)
+	}
+}
+
+// SetCountry stores the response of a country like SetCode
+func (d *Directory) SetCountry(countryCode string, response any, gen uint64) {
+	if d != nil {
+		d.set(d.Countries, countryCode, response, gen)
+	}
+}
+
+func (d *Directory) set(lru *LRU[any], key string, response any, gen uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.generation == gen {
+		lru.Set(key, response)
+	}
+}
+
+// InvalidateBank drops every entry that can contain the given bank:
+// the code itself, its headquarter (which lists its branches) and its country
+func (d *Directory) InvalidateBank(swiftCode, countryCode string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.generation++
+
+	for _, code := range uniq(swiftCode, strings.ToUpper(swiftCode)) {
+		d.Codes.Delete(code)
+		if len(code) >= 8 {
+			d.Codes.Delete(code[:8] + "XXX")
+		}
+	}
+
+	for _, country := range uniq(countryCode, strings.ToUpper(countryCode)) {
+		d.Countries.Delete(country)
+	}
+}
+
+// InvalidateBanks is used after an import
+func (d *Directory) InvalidateBanks(banks []model.Bank) {
+	for _, b := range banks {
+		d.InvalidateBank(b.SwiftCode, b.CountryCode)
+	}
+}
+
+func (d *Directory) Purge() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.generation++
+	d.Codes.Purge()
+	d.Countries.Purge()
+}
+
+func (d *Directory) Stats() map[string]Stats {
+	return map[string]Stats{
+		"codes":     d.Codes.Stats(),
+		"countries": d.Countries.Stats(),
+	}
+}
+
+func uniq(a, b string) []string {
+	if a == b {
+		return []string{a}
+	}
+	return []string{a, b}
+}
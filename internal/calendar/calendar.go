@@ -0,0 +1,190 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// how far NextCutoff and the business day searches look for a business day
+const maxSearchDays = 366
+
+// ErrNoBusinessDay is returned when there is no business day within a year
+var ErrNoBusinessDay = errors.New("no business day within a year")
+
+// TimeOfDay is a wall clock time in the bank's time zone
+type TimeOfDay struct {
+	Hour, Minute int
+}
+
+// ParseTimeOfDay reads a 24 hour "HH:MM" time
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+func (t TimeOfDay) minutes() int {
+	return t.Hour*60 + t.Minute
+}
+
+// On returns the time of day on the date of day, in the location of day
+func (t TimeOfDay) On(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour, t.Minute, 0, 0, day.Location())
+}
+
+// Hours are the business hours and the payment cutoff, the same on every business day
+type Hours struct {
+	Open   TimeOfDay
+	Close  TimeOfDay
+	Cutoff TimeOfDay // last time a payment is processed the same day
+}
+
+func (h Hours) Validate() error {
+	if h.Open.minutes() >= h.Close.minutes() {
+		return fmt.Errorf("business hours open at %s, after closing at %s", h.Open, h.Close)
+	}
+	if h.Cutoff.minutes() < h.Open.minutes() || h.Cutoff.minutes() > h.Close.minutes() {
+		return fmt.Errorf("cutoff %s is outside the business hours %s-%s", h.Cutoff, h.Open, h.Close)
+	}
+	return nil
+}
+
+// Holidays holds the bank holidays of each country
+type Holidays struct {
+	days map[string]map[string]string // country -> date -> name
+}
+
+// NewHolidays returns the holidays of the list
+func NewHolidays(list ...model.Holiday) *Holidays {
+	h := &Holidays{days: map[string]map[string]string{}}
+	for _, hd := range list {
+		h.Add(hd)
+	}
+	return h
+}
+
+// Add adds a holiday, a second holiday on the same date replaces the name
+func (h *Holidays) Add(hd model.Holiday) {
+	countryCode := strings.ToUpper(hd.CountryCode)
+	if h.days[countryCode] == nil {
+		h.days[countryCode] = map[string]string{}
+	}
+	h.days[countryCode][hd.Date] = hd.Name
+}
+
+// Holiday returns the name of the holiday on the date of day (in day's location)
+func (h *Holidays) Holiday(countryCode string, day time.Time) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	name, ok := h.days[strings.ToUpper(countryCode)][day.Format(model.DateLayout)]
+	return name, ok
+}
+
+// Len returns the number of holidays of all countries
+func (h *Holidays) Len() int {
+	n := 0
+	for _, days := range h.days {
+		n += len(days)
+	}
+	return n
+}
+
+// Calendar decides business days and hours of the banks of a country
+type Calendar struct {
+	Hours    Hours
+	Holidays *Holidays // nil when only weekends are closed
+}
+
+// IsBusinessDay reports whether the date of day is neither a weekend nor a holiday
+func (c Calendar) IsBusinessDay(countryCode string, day time.Time) bool {
+	if IsWeekend(day) {
+		return false
+	}
+	_, holiday := c.Holidays.Holiday(countryCode, day)
+	return !holiday
+}
+
+// IsWeekend reports whether day is a Saturday or a Sunday
+func IsWeekend(day time.Time) bool {
+	wd := day.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+// NextBusinessDay returns the first business day after the date of day, at midnight
+func (c Calendar) NextBusinessDay(countryCode string, day time.Time) (time.Time, error) {
+	return c.findBusinessDay(countryCode, day, 1)
+}
+
+// PreviousBusinessDay returns the last business day before the date of day, at midnight
+func (c Calendar) PreviousBusinessDay(countryCode string, day time.Time) (time.Time, error) {
+	return c.findBusinessDay(countryCode, day, -1)
+}
+
+func (c Calendar) findBusinessDay(countryCode string, day time.Time, step int) (time.Time, error) {
+	y, m, d := day.Date()
+	for i := 1; i <= maxSearchDays; i++ {
+		next := time.Date(y, m, d+i*step, 0, 0, 0, 0, day.Location())
+		if c.IsBusinessDay(countryCode, next) {
+			return next, nil
+		}
+	}
+	return time.Time{}, ErrNoBusinessDay
+}
+
+// Clock is the state of a bank at one moment
+type Clock struct {
+	LocalTime   time.Time
+	BusinessDay bool
+	Holiday     string // name of today's holiday
+	Open        bool   // within business hours of a business day
+	NextCutoff  time.Time
+}
+
+// At returns the clock of a bank in loc and countryCode at the instant now.
+// NextCutoff is the first cutoff at or after now on a business day.
+func (c Calendar) At(now time.Time, loc *time.Location, countryCode string) (Clock, error) {
+	local := now.In(loc)
+	clock := Clock{LocalTime: local, BusinessDay: c.IsBusinessDay(countryCode, local)}
+	clock.Holiday, _ = c.Holidays.Holiday(countryCode, local)
+
+	if clock.BusinessDay {
+		open, closing := c.Hours.Open.On(local), c.Hours.Close.On(local)
+		clock.Open = !local.Before(open) && local.Before(closing)
+	}
+
+	cutoff, err := c.NextCutoff(now, loc, countryCode)
+	if err != nil {
+		return clock, err
+	}
+	clock.NextCutoff = cutoff
+	return clock, nil
+}
+
+// NextCutoff returns the first cutoff at or after now, skipping weekends and holidays
+func (c Calendar) NextCutoff(now time.Time, loc *time.Location, countryCode string) (time.Time, error) {
+	day := now.In(loc)
+	for i := 0; i < maxSearchDays; i++ {
+		if c.IsBusinessDay(countryCode, day) {
+			cutoff := c.Hours.Cutoff.On(day)
+			if !cutoff.Before(now) {
+				return cutoff, nil
+			}
+		}
+		// noon avoids skipping or repeating a date around DST changes
+		y, m, d := day.Date()
+		day = time.Date(y, m, d+1, 12, 0, 0, 0, loc)
+	}
+	return time.Time{}, ErrNoBusinessDay
+}
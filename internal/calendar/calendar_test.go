@@ -0,0 +1,157 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/calendar"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	tm, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tod, err := calendar.ParseTimeOfDay("09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, calendar.TimeOfDay{Hour: 9, Minute: 30}, tod)
+	assert.Equal(t, "09:30", tod.String())
+
+	for _, s := range []string{"", "9", "24:00", "12:60", "noon"} {
+		_, err := calendar.ParseTimeOfDay(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestHours_Validate(t *testing.T) {
+	h := calendar.Hours{Open: calendar.TimeOfDay{Hour: 9}, Close: calendar.TimeOfDay{Hour: 17}, Cutoff: calendar.TimeOfDay{Hour: 16}}
+	assert.NoError(t, h.Validate())
+
+	closed := h
+	closed.Close = calendar.TimeOfDay{Hour: 9}
+	assert.Error(t, closed.Validate(), "closing at opening time")
+
+	late := h
+	late.Cutoff = calendar.TimeOfDay{Hour: 18}
+	assert.Error(t, late.Validate(), "cutoff after closing")
+}
+
+func loadHolidays(t *testing.T) *calendar.Holidays {
+	t.Helper()
+	list, err := parser.ParseHolidayCSV("../../data/holidays.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calendar.NewHolidays(list...)
+}
+
+func TestHolidays(t *testing.T) {
+	h := loadHolidays(t)
+	assert.Positive(t, h.Len())
+
+	name, ok := h.Holiday("pl", mustTime(t, "2026-11-11T10:00:00+01:00"))
+	assert.True(t, ok)
+	assert.Equal(t, "Independence Day", name)
+
+	_, ok = h.Holiday("DE", mustTime(t, "2026-12-25T10:00:00+01:00"))
+	assert.False(t, ok, "Holidays are per country")
+
+	h.Add(model.Holiday{CountryCode: "de", Date: "2026-12-25", Name: "Weihnachten"})
+	name, ok = h.Holiday("DE", mustTime(t, "2026-12-25T10:00:00+01:00"))
+	assert.True(t, ok)
+	assert.Equal(t, "Weihnachten", name)
+}
+
+func TestCalendar_BusinessDays(t *testing.T) {
+	cal := calendar.Calendar{Holidays: loadHolidays(t)}
+	date := func(s string) time.Time {
+		d, err := time.Parse(model.DateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		date        string
+		businessDay bool
+		next        string
+		previous    string
+	}{
+		{"2026-05-01", false, "2026-05-04", "2026-04-30"}, // Labour Day, a Friday
+		{"2026-05-04", true, "2026-05-05", "2026-04-30"},
+		{"2026-05-02", false, "2026-05-04", "2026-04-30"}, // Saturday
+		{"2026-12-24", false, "2026-12-28", "2026-12-23"}, // Christmas Eve to Boxing Day, then a weekend
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			assert.Equal(t, tt.businessDay, cal.IsBusinessDay("PL", date(tt.date)))
+
+			next, err := cal.NextBusinessDay("PL", date(tt.date))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, next.Format(model.DateLayout))
+
+			previous, err := cal.PreviousBusinessDay("PL", date(tt.date))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.previous, previous.Format(model.DateLayout))
+		})
+	}
+
+	assert.True(t, calendar.IsWeekend(date("2026-05-03")))
+	assert.False(t, calendar.IsWeekend(date("2026-05-01")))
+}
+
+func TestCalendar_At(t *testing.T) {
+	cal := calendar.Calendar{
+		Hours:    calendar.Hours{Open: calendar.TimeOfDay{Hour: 9}, Close: calendar.TimeOfDay{Hour: 17}, Cutoff: calendar.TimeOfDay{Hour: 16}},
+		Holidays: loadHolidays(t),
+	}
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		name        string
+		now         string
+		businessDay bool
+		open        bool
+		holiday     string
+		nextCutoff  string
+	}{
+		{"before the cutoff", "2026-04-30T13:00:00Z", true, true, "", "2026-04-30T14:00:00Z"},
+		{"after the cutoff, before a holiday and a weekend", "2026-04-30T14:30:00Z", true, true, "", "2026-05-04T14:00:00Z"},
+		{"at the cutoff", "2026-04-30T14:00:00Z", true, true, "", "2026-04-30T14:00:00Z"},
+		{"holiday", "2026-05-01T08:00:00Z", false, false, "Labour Day", "2026-05-04T14:00:00Z"},
+		{"before opening", "2026-05-04T06:00:00Z", true, false, "", "2026-05-04T14:00:00Z"},
+		{"after closing", "2026-05-04T15:00:00Z", true, false, "", "2026-05-05T14:00:00Z"},
+		{"across the change to summer time", "2026-03-27T16:00:00Z", true, false, "", "2026-03-30T14:00:00Z"},
+		{"christmas", "2026-12-23T16:00:00Z", true, false, "", "2026-12-28T15:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, err := cal.At(mustTime(t, tt.now), warsaw, "PL")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.businessDay, clock.BusinessDay)
+			assert.Equal(t, tt.open, clock.Open)
+			assert.Equal(t, tt.holiday, clock.Holiday)
+			assert.Equal(t, mustTime(t, tt.nextCutoff), clock.NextCutoff.UTC())
+			assert.Equal(t, warsaw, clock.LocalTime.Location())
+		})
+	}
+
+	// without holidays only weekends are closed
+	weekends := calendar.Calendar{Hours: cal.Hours}
+	clock, err := weekends.At(mustTime(t, "2026-05-01T08:00:00Z"), warsaw, "PL")
+	assert.NoError(t, err)
+	assert.True(t, clock.Open)
+	assert.Equal(t, mustTime(t, "2026-05-01T14:00:00Z"), clock.NextCutoff.UTC())
+}
@@ -0,0 +1,672 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/calendar"
+	"github.com/white67/swift_api/internal/ratelimit"
+	"github.com/white67/swift_api/internal/screening"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the effective service configuration.
+// Values are resolved in order: defaults < config file < environment < flags.
+type Config struct {
+	Server        ServerConfig        `yaml:"server"`
+	Database      DBConfig            `yaml:"database"`
+	SeedFile      string              `yaml:"seedFile"`
+	GazetteerFile string              `yaml:"gazetteerFile"` // town coordinates, empty disables geocoding and town searches
+	Cache         CacheConfig         `yaml:"cache"`
+	RateLimit     RateLimitConfig     `yaml:"rateLimit"`
+	Admin         AdminConfig         `yaml:"admin"`
+	Log           LogConfig           `yaml:"log"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+	Webhooks      WebhookConfig       `yaml:"webhooks"`
+	Events        EventsConfig        `yaml:"events"`
+	IBAN          IBANConfig          `yaml:"iban"`
+	BusinessHours BusinessHoursConfig `yaml:"businessHours"`
+	Screening     ScreeningConfig     `yaml:"screening"`
+}
+
+type ServerConfig struct {
+	Addr             string        `yaml:"addr"`
+	GRPCAddr         string        `yaml:"grpcAddr"` // gRPC listen address, empty disables the gRPC server
+	TLSCertFile      string        `yaml:"tlsCertFile"`
+	TLSKeyFile       string        `yaml:"tlsKeyFile"`
+	ReadTimeout      time.Duration `yaml:"readTimeout"`
+	WriteTimeout     time.Duration `yaml:"writeTimeout"`
+	IdleTimeout      time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout  time.Duration `yaml:"shutdownTimeout"`  // time to drain requests on SIGINT/SIGTERM
+	ValidateRequests bool          `yaml:"validateRequests"` // reject requests that do not match the OpenAPI spec
+	SwaggerUI        bool          `yaml:"swaggerUI"`        // serve Swagger UI at /docs
+}
+
+// DBConfig holds either a full DSN or its parts (DSN wins when both are set)
+type DBConfig struct {
+	DSN             string        `yaml:"dsn"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslMode"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+}
+
+type CacheConfig struct {
+	Size int           `yaml:"size"` // 0 disables the cache
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn, error
+	Format string `yaml:"format"` // json or text
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"` // none, otlp or stdout
+	Endpoint    string  `yaml:"endpoint"` // OTLP/HTTP collector, e.g. otel-collector:4318
+	Insecure    bool    `yaml:"insecure"`
+	File        string  `yaml:"file"` // stdout exporter writes here instead of stdout
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+type WebhookConfig struct {
+	Interval    time.Duration `yaml:"interval"` // poll interval for due deliveries, 0 disables sending
+	Timeout     time.Duration `yaml:"timeout"`
+	MaxAttempts int           `yaml:"maxAttempts"`
+	Backoff     time.Duration `yaml:"backoff"` // wait after the first failure, doubled after each one
+	MaxBackoff  time.Duration `yaml:"maxBackoff"`
+	// subscriptions and deliveries to loopback, link-local and private addresses
+	AllowPrivateTargets bool `yaml:"allowPrivateTargets"`
+}
+
+// the events table and the /v1/events streams
+type EventsConfig struct {
+	Retention           time.Duration `yaml:"retention"`           // events older than this are deleted, 0 keeps every event
+	MaxStreamsPerClient int           `yaml:"maxStreamsPerClient"` // open /v1/events streams per issued API key or IP, 0 removes the cap
+}
+
+// offline IBAN data, an empty registry file disables /v1/iban
+type IBANConfig struct {
+	RegistryFile  string `yaml:"registryFile"`  // IBAN formats per country
+	BankCodesFile string `yaml:"bankCodesFile"` // national bank code to BIC, optional
+}
+
+// local business hours of every bank as HH:MM, payments made after the cutoff
+// are processed on the next business day
+type BusinessHoursConfig struct {
+	Open         string `yaml:"open"`
+	Close        string `yaml:"close"`
+	Cutoff       string `yaml:"cutoff"`
+	HolidaysFile string `yaml:"holidaysFile"` // imported into an empty holidays table at startup
+}
+
+// Hours parses the business hours
+func (b BusinessHoursConfig) Hours() (calendar.Hours, error) {
+	var h calendar.Hours
+	var err error
+	if h.Open, err = calendar.ParseTimeOfDay(b.Open); err != nil {
+		return h, fmt.Errorf("business hours open: %w", err)
+	}
+	if h.Close, err = calendar.ParseTimeOfDay(b.Close); err != nil {
+		return h, fmt.Errorf("business hours close: %w", err)
+	}
+	if h.Cutoff, err = calendar.ParseTimeOfDay(b.Cutoff); err != nil {
+		return h, fmt.Errorf("payment cutoff: %w", err)
+	}
+	return h, h.Validate()
+}
+
+// sanctions screening of bank names, run by swiftctl screen and import-sanctions
+type ScreeningConfig struct {
+	Threshold float64 `yaml:"threshold"` // lowest similarity (0-1] of a name reported as a hit
+}
+
+// limits in the ratelimit.ParseLimit format, e.g. "rate=5,burst=20,quota=5000".
+// The *Key limits apply to clients sending one of APIKeys in X-API-Key, other keys
+// are limited like clients without a key.
+type RateLimitConfig struct {
+	APIKeys    string `yaml:"apiKeys"` // name=key,name=key, the name identifies the client
+	LookupIP   string `yaml:"lookupIP"`
+	LookupKey  string `yaml:"lookupKey"`
+	DefaultIP  string `yaml:"defaultIP"`
+	DefaultKey string `yaml:"defaultKey"`
+	ExportIP   string `yaml:"exportIP"` // full exports, the whole directory in one response
+	ExportKey  string `yaml:"exportKey"`
+}
+
+// admin routes (webhooks, screening decisions, /v1/admin) need one of these keys as a
+// bearer token, without keys they are disabled
+type AdminConfig struct {
+	Keys string `yaml:"keys"` // name=key,name=key, the name is recorded as the principal
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:             ":8080",
+			GRPCAddr:         ":9090",
+			ReadTimeout:      10 * time.Second,
+			WriteTimeout:     30 * time.Second,
+			IdleTimeout:      60 * time.Second,
+			ShutdownTimeout:  15 * time.Second,
+			ValidateRequests: true,
+			SwaggerUI:        true,
+		},
+		Database: DBConfig{
+			Port:            "5432",
+			SSLMode:         "disable",
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		SeedFile:      "data/2025_SWIFT_CODES.csv",
+		GazetteerFile: "data/gazetteer.csv",
+		Cache: CacheConfig{
+			Size: 10000,
+			TTL:  5 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			LookupIP:   "rate=5,burst=20,quota=5000",
+			LookupKey:  "rate=50,burst=100",
+			DefaultIP:  "rate=10,burst=30",
+			DefaultKey: "rate=100,burst=200",
+			ExportIP:   "rate=0.1,burst=2,quota=20",
+			ExportKey:  "rate=1,burst=5,quota=500",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		IBAN: IBANConfig{
+			RegistryFile:  "data/iban_registry.csv",
+			BankCodesFile: "data/iban_bank_codes.csv",
+		},
+		BusinessHours: BusinessHoursConfig{
+			Open:         "09:00",
+			Close:        "17:00",
+			Cutoff:       "16:00",
+			HolidaysFile: "data/holidays.csv",
+		},
+		Screening: ScreeningConfig{
+			Threshold: screening.DefaultThreshold,
+		},
+		Webhooks: WebhookConfig{
+			Interval:    5 * time.Second,
+			Timeout:     10 * time.Second,
+			MaxAttempts: 8,
+			Backoff:     30 * time.Second,
+			MaxBackoff:  time.Hour,
+		},
+		Events: EventsConfig{
+			Retention:           30 * 24 * time.Hour,
+			MaxStreamsPerClient: 5,
+		},
+	}
+}
+
+// Load builds the configuration from an optional file (-config flag or CONFIG_FILE),
+// the environment (and a .env file outside production) and command line flags
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("swift_api", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a YAML config file")
+	flags := bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	loadDotEnv()
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	// only flags given on the command line override the other sources
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := flags[f.Name]; ok && flagErr == nil {
+			flagErr = apply(&cfg, f.Value.String())
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// .env is optional, variables already set in the environment win
+func loadDotEnv() {
+	if os.Getenv("ENV") == "production" {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if rootPath := findRootEnvPath(cwd); rootPath != "" {
+		_ = godotenv.Load(filepath.Join(rootPath, ".env"))
+	}
+}
+
+// setter applies a raw string value to the config
+type setter func(cfg *Config, value string) error
+
+func stringSetter(field func(cfg *Config) *string) setter {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func intSetter(name string, field func(cfg *Config) *int) setter {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func boolSetter(name string, field func(cfg *Config) *bool) setter {
+	return func(cfg *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		*field(cfg) = b
+		return nil
+	}
+}
+
+func floatSetter(name string, field func(cfg *Config) *float64) setter {
+	return func(cfg *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		*field(cfg) = f
+		return nil
+	}
+}
+
+func durationSetter(name string, field func(cfg *Config) *time.Duration) setter {
+	return func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		*field(cfg) = d
+		return nil
+	}
+}
+
+// every option with its environment variable and flag name (empty = no flag)
+var options = []struct {
+	env  string
+	flag string
+	help string
+	set  func(name string) setter
+}{
+	{"LISTEN_ADDR", "addr", "listen address", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Server.Addr }) }},
+	{"GRPC_ADDR", "grpc-addr", "gRPC listen address, empty disables gRPC", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Server.GRPCAddr }) }},
+	{"TLS_CERT_FILE", "tls-cert", "TLS certificate file", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Server.TLSCertFile }) }},
+	{"TLS_KEY_FILE", "tls-key", "TLS key file", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Server.TLSKeyFile }) }},
+	{"READ_TIMEOUT", "read-timeout", "HTTP read timeout", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Server.ReadTimeout })
+	}},
+	{"WRITE_TIMEOUT", "write-timeout", "HTTP write timeout", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Server.WriteTimeout })
+	}},
+	{"IDLE_TIMEOUT", "idle-timeout", "HTTP keep-alive idle timeout", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Server.IdleTimeout })
+	}},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time to drain requests on shutdown", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })
+	}},
+	{"VALIDATE_REQUESTS", "validate-requests", "validate requests against the OpenAPI spec", func(n string) setter {
+		return boolSetter(n, func(c *Config) *bool { return &c.Server.ValidateRequests })
+	}},
+	{"SWAGGER_UI", "swagger-ui", "serve Swagger UI at /docs", func(n string) setter {
+		return boolSetter(n, func(c *Config) *bool { return &c.Server.SwaggerUI })
+	}},
+	{"DB_DSN", "db-dsn", "full database DSN (overrides the other DB settings)", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.DSN }) }},
+	{"DB_HOST", "db-host", "database host", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.Host }) }},
+	{"DB_PORT", "db-port", "database port", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.Port }) }},
+	{"DB_USER", "db-user", "database user", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.User }) }},
+	// no flag: passwords on the command line end up in the process list
+	{"DB_PASSWORD", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.Password }) }},
+	{"DB_NAME", "db-name", "database name", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.Name }) }},
+	{"DB_SSLMODE", "db-sslmode", "database SSL mode", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Database.SSLMode }) }},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections", func(n string) setter {
+		return intSetter(n, func(c *Config) *int { return &c.Database.MaxOpenConns })
+	}},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", func(n string) setter {
+		return intSetter(n, func(c *Config) *int { return &c.Database.MaxIdleConns })
+	}},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum lifetime of a database connection", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime })
+	}},
+	{"SEED_FILE", "seed-file", "CSV file imported when the database is empty", func(string) setter { return stringSetter(func(c *Config) *string { return &c.SeedFile }) }},
+	{"GAZETTEER_FILE", "gazetteer-file", "town coordinates, empty disables geocoding", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.GazetteerFile })
+	}},
+	{"CACHE_SIZE", "cache-size", "entries per lookup cache, 0 disables caching", func(n string) setter {
+		return intSetter(n, func(c *Config) *int { return &c.Cache.Size })
+	}},
+	{"CACHE_TTL", "cache-ttl", "maximum age of a cache entry", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Cache.TTL })
+	}},
+	{"LOG_LEVEL", "log-level", "log level (debug, info, warn, error)", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Log.Level }) }},
+	{"LOG_FORMAT", "log-format", "log format (json or text)", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Log.Format }) }},
+	{"TRACING_EXPORTER", "tracing-exporter", "trace exporter (none, otlp or stdout)", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Tracing.Exporter }) }},
+	{"TRACING_ENDPOINT", "tracing-endpoint", "OTLP/HTTP endpoint (host:port)", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Tracing.Endpoint }) }},
+	{"TRACING_INSECURE", "tracing-insecure", "use plain HTTP for the OTLP endpoint", func(n string) setter {
+		return boolSetter(n, func(c *Config) *bool { return &c.Tracing.Insecure })
+	}},
+	{"TRACING_FILE", "tracing-file", "file for the stdout trace exporter", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Tracing.File }) }},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of traces to record", func(n string) setter {
+		return floatSetter(n, func(c *Config) *float64 { return &c.Tracing.SampleRatio })
+	}},
+	{"WEBHOOK_INTERVAL", "webhook-interval", "poll interval for webhook deliveries, 0 disables sending", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Webhooks.Interval })
+	}},
+	{"WEBHOOK_TIMEOUT", "webhook-timeout", "timeout of a webhook request", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Webhooks.Timeout })
+	}},
+	{"WEBHOOK_MAX_ATTEMPTS", "webhook-max-attempts", "attempts before a webhook delivery fails", func(n string) setter {
+		return intSetter(n, func(c *Config) *int { return &c.Webhooks.MaxAttempts })
+	}},
+	{"WEBHOOK_BACKOFF", "webhook-backoff", "wait after the first failed webhook attempt", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Webhooks.Backoff })
+	}},
+	{"WEBHOOK_MAX_BACKOFF", "webhook-max-backoff", "maximum wait between webhook attempts", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Webhooks.MaxBackoff })
+	}},
+	{"WEBHOOK_ALLOW_PRIVATE_TARGETS", "webhook-allow-private-targets", "allow webhooks to loopback, link-local and private addresses", func(n string) setter {
+		return boolSetter(n, func(c *Config) *bool { return &c.Webhooks.AllowPrivateTargets })
+	}},
+	{"EVENTS_RETENTION", "events-retention", "age after which events are deleted, 0 keeps every event", func(n string) setter {
+		return durationSetter(n, func(c *Config) *time.Duration { return &c.Events.Retention })
+	}},
+	{"EVENTS_MAX_STREAMS_PER_CLIENT", "events-max-streams-per-client", "open event streams per issued API key or IP, 0 removes the cap", func(n string) setter {
+		return intSetter(n, func(c *Config) *int { return &c.Events.MaxStreamsPerClient })
+	}},
+	{"IBAN_REGISTRY_FILE", "iban-registry-file", "IBAN formats per country, empty disables IBAN validation", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.IBAN.RegistryFile })
+	}},
+	{"IBAN_BANK_CODES_FILE", "iban-bank-codes-file", "national bank code to BIC table", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.IBAN.BankCodesFile })
+	}},
+	{"BUSINESS_HOURS_OPEN", "business-hours-open", "local opening time of banks (HH:MM)", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.BusinessHours.Open })
+	}},
+	{"BUSINESS_HOURS_CLOSE", "business-hours-close", "local closing time of banks (HH:MM)", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.BusinessHours.Close })
+	}},
+	{"PAYMENT_CUTOFF", "payment-cutoff", "local time after which payments move to the next business day (HH:MM)", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.BusinessHours.Cutoff })
+	}},
+	{"HOLIDAYS_FILE", "holidays-file", "bank holidays imported into an empty holidays table at startup", func(string) setter {
+		return stringSetter(func(c *Config) *string { return &c.BusinessHours.HolidaysFile })
+	}},
+	{"SCREENING_THRESHOLD", "screening-threshold", "lowest name similarity (0-1] reported as a sanctions screening hit", func(n string) setter {
+		return floatSetter(n, func(c *Config) *float64 { return &c.Screening.Threshold })
+	}},
+	// no flag: keys on the command line end up in the process list
+	{"ADMIN_KEYS", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.Admin.Keys }) }},
+	{"API_KEYS", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.APIKeys }) }},
+	{"RATE_LIMIT_LOOKUP_IP", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.LookupIP }) }},
+	{"RATE_LIMIT_LOOKUP_KEY", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.LookupKey }) }},
+	{"RATE_LIMIT_DEFAULT_IP", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.DefaultIP }) }},
+	{"RATE_LIMIT_DEFAULT_KEY", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.DefaultKey }) }},
+	{"RATE_LIMIT_EXPORT_IP", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.ExportIP }) }},
+	{"RATE_LIMIT_EXPORT_KEY", "", "", func(string) setter { return stringSetter(func(c *Config) *string { return &c.RateLimit.ExportKey }) }},
+}
+
+// a variable that is set but empty is applied too, e.g. GRPC_ADDR= disables gRPC
+func applyEnv(cfg *Config) error {
+	for _, opt := range options {
+		value, ok := os.LookupEnv(opt.env)
+		if !ok {
+			continue
+		}
+		if err := opt.set(opt.env)(cfg, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registers the flags, values are applied after parsing so defaults do not override env
+func bindFlags(fs *flag.FlagSet) map[string]setter {
+	flags := make(map[string]setter)
+	for _, opt := range options {
+		if opt.flag == "" {
+			continue
+		}
+		fs.String(opt.flag, "", opt.help+" (env "+opt.env+")")
+		flags[opt.flag] = opt.set("-" + opt.flag)
+	}
+	return flags
+}
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("listen address must not be empty"))
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS needs both a certificate and a key file"))
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 || c.Server.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("timeouts must not be negative"))
+	}
+
+	if c.Database.DSN == "" {
+		if c.Database.Host == "" {
+			errs = append(errs, errors.New("database host is required (DB_HOST or DB_DSN)"))
+		}
+		if c.Database.User == "" {
+			errs = append(errs, errors.New("database user is required (DB_USER or DB_DSN)"))
+		}
+		if c.Database.Name == "" {
+			errs = append(errs, errors.New("database name is required (DB_NAME or DB_DSN)"))
+		}
+		if !sslModes[c.Database.SSLMode] {
+			errs = append(errs, fmt.Errorf("invalid database SSL mode %q", c.Database.SSLMode))
+		}
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database pool sizes must not be negative"))
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database idle connections must not exceed open connections"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("invalid log level %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("invalid log format %q", c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("invalid trace exporter %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("trace sample ratio must be between 0 and 1"))
+	}
+
+	if c.Webhooks.Interval < 0 || c.Webhooks.Timeout <= 0 || c.Webhooks.Backoff <= 0 || c.Webhooks.MaxBackoff < c.Webhooks.Backoff {
+		errs = append(errs, errors.New("webhook timeout and backoff must be positive, the maximum backoff at least the backoff"))
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhook attempts must be at least 1"))
+	}
+
+	if c.Events.Retention < 0 || c.Events.MaxStreamsPerClient < 0 {
+		errs = append(errs, errors.New("event retention and streams per client must not be negative"))
+	}
+
+	if c.Cache.Size < 0 {
+		errs = append(errs, errors.New("cache size must not be negative"))
+	}
+
+	if _, err := c.BusinessHours.Hours(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Screening.Threshold <= 0 || c.Screening.Threshold > 1 {
+		errs = append(errs, errors.New("screening threshold must be above 0 and at most 1"))
+	}
+
+	for name, spec := range map[string]string{
+		"lookupIP":   c.RateLimit.LookupIP,
+		"lookupKey":  c.RateLimit.LookupKey,
+		"defaultIP":  c.RateLimit.DefaultIP,
+		"defaultKey": c.RateLimit.DefaultKey,
+		"exportIP":   c.RateLimit.ExportIP,
+		"exportKey":  c.RateLimit.ExportKey,
+	} {
+		if _, err := ratelimit.ParseLimit(spec, ratelimit.Limit{}); err != nil {
+			errs = append(errs, fmt.Errorf("rate limit %s: %w", name, err))
+		}
+	}
+
+	if _, err := auth.ParseKeys(c.RateLimit.APIKeys); err != nil {
+		errs = append(errs, fmt.Errorf("API keys: %w", err))
+	}
+	if _, err := auth.ParseKeys(c.Admin.Keys); err != nil {
+		errs = append(errs, fmt.Errorf("admin keys: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ConnString returns the lib/pq connection string
+func (d DBConfig) ConnString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(d.Host), quoteDSN(d.Port), quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), quoteDSN(d.SSLMode),
+	)
+}
+
+// values with spaces or quotes must be single-quoted in key=value DSNs
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`password=('(\\.|[^'])*'|\S+)`)
+
+// the key after each name in ADMIN_KEYS and API_KEYS
+var adminKey = regexp.MustCompile(`=[^,]*`)
+
+// Redacted returns a copy that is safe to log
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = redacted
+	}
+	if c.Database.DSN != "" {
+		if u, err := url.Parse(c.Database.DSN); err == nil && u.Scheme != "" {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+			if q := u.Query(); q.Has("password") {
+				q.Set("password", redacted)
+				u.RawQuery = q.Encode()
+			}
+			c.Database.DSN = u.String()
+		} else {
+			c.Database.DSN = dsnPassword.ReplaceAllString(c.Database.DSN, "password="+redacted)
+		}
+	}
+	if c.Admin.Keys != "" {
+		c.Admin.Keys = adminKey.ReplaceAllString(c.Admin.Keys, "="+redacted)
+	}
+	if c.RateLimit.APIKeys != "" {
+		c.RateLimit.APIKeys = adminKey.ReplaceAllString(c.RateLimit.APIKeys, "="+redacted)
+	}
+	return c
+}
+
+// String prints the effective configuration with secrets redacted
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
@@ -0,0 +1,156 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/config"
+)
+
+func setDBEnv(t *testing.T) {
+	t.Setenv("ENV", "production") // do not pick up a local .env file
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USER", "bank_manager")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "swiftdb")
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	setDBEnv(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+server:
+  addr: ":9000"
+  readTimeout: 3s
+seedFile: file.csv
+cache:
+  size: 50
+`), 0644)
+	assert.NoError(t, err)
+
+	// env overrides the file, flags override env
+	t.Setenv("SEED_FILE", "env.csv")
+	t.Setenv("CACHE_SIZE", "60")
+
+	cfg, err := config.Load([]string{"-config", path, "-cache-size", "70"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, ":9000", cfg.Server.Addr, "File value should override default")
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout, "Unset values keep their default")
+	assert.Equal(t, "env.csv", cfg.SeedFile, "Env should override file")
+	assert.Equal(t, 70, cfg.Cache.Size, "Flag should override env")
+	assert.Equal(t, "localhost", cfg.Database.Host)
+}
+
+func TestLoad_EmptyEnv(t *testing.T) {
+	setDBEnv(t)
+
+	// set but empty disables the optional features
+	t.Setenv("GRPC_ADDR", "")
+	t.Setenv("GAZETTEER_FILE", "")
+	t.Setenv("IBAN_REGISTRY_FILE", "")
+
+	cfg, err := config.Load(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.Server.GRPCAddr)
+	assert.Empty(t, cfg.GazetteerFile)
+	assert.Empty(t, cfg.IBAN.RegistryFile)
+	assert.Equal(t, "data/2025_SWIFT_CODES.csv", cfg.SeedFile, "Unset variables keep their default")
+
+	t.Setenv("CACHE_SIZE", "")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "CACHE_SIZE")
+}
+
+func TestLoad_Validation(t *testing.T) {
+	setDBEnv(t)
+
+	_, err := config.Load([]string{"-db-sslmode", "sometimes"})
+	assert.ErrorContains(t, err, "SSL mode")
+
+	_, err = config.Load([]string{"-tls-cert", "cert.pem"})
+	assert.ErrorContains(t, err, "TLS")
+
+	_, err = config.Load([]string{"-read-timeout", "soon"})
+	assert.Error(t, err)
+
+	_, err = config.Load([]string{"-log-level", "loud"})
+	assert.ErrorContains(t, err, "log level")
+
+	_, err = config.Load([]string{"-tracing-sample-ratio", "2"})
+	assert.ErrorContains(t, err, "sample ratio")
+
+	_, err = config.Load([]string{"-screening-threshold", "0"})
+	assert.ErrorContains(t, err, "screening threshold")
+
+	_, err = config.Load([]string{"-events-max-streams-per-client", "-1"})
+	assert.ErrorContains(t, err, "streams per client")
+
+	t.Setenv("ADMIN_KEYS", "alice=short")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "admin key")
+	t.Setenv("ADMIN_KEYS", "")
+
+	t.Setenv("API_KEYS", "team-a")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "API keys")
+	t.Setenv("API_KEYS", "")
+
+	t.Setenv("DB_HOST", "")
+	_, err = config.Load(nil)
+	assert.ErrorContains(t, err, "database host")
+
+	// a DSN replaces the individual settings
+	t.Setenv("DB_DSN", "postgres://bank_manager:secret@db:5432/swiftdb?sslmode=require")
+	cfg, err := config.Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://bank_manager:secret@db:5432/swiftdb?sslmode=require", cfg.Database.ConnString())
+}
+
+func TestConnString(t *testing.T) {
+	db := config.DBConfig{
+		Host:     "localhost",
+		Port:     "5432",
+		User:     "bank_manager",
+		Password: "it's secret",
+		Name:     "swiftdb",
+		SSLMode:  "require",
+	}
+
+	assert.Equal(t,
+		`host=localhost port=5432 user=bank_manager password='it\'s secret' dbname=swiftdb sslmode=require`,
+		db.ConnString(),
+	)
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Password = "secret"
+	cfg.Database.DSN = "postgres://bank_manager:secret@db:5432/swiftdb"
+	cfg.Admin.Keys = "alice=alice-secret-0123456789,bob=bob-secret-0123456789"
+	cfg.RateLimit.APIKeys = "team-a=team-a-secret-0123456789"
+
+	out := cfg.String()
+	assert.NotContains(t, out, "secret")
+	assert.Contains(t, out, "alice=REDACTED,bob=REDACTED")
+	assert.Contains(t, out, "team-a=REDACTED")
+	assert.Contains(t, out, "REDACTED")
+
+	cfg.Database.DSN = "host=db user=bank_manager password='very secret' dbname=swiftdb"
+	redacted := cfg.Redacted()
+	assert.Equal(t, "host=db user=bank_manager password=REDACTED dbname=swiftdb", redacted.Database.DSN)
+
+	cfg.Database.DSN = "postgres://bank_manager@db:5432/swiftdb?sslmode=require&password=secret"
+	redacted = cfg.Redacted()
+	assert.Equal(t, "postgres://bank_manager@db:5432/swiftdb?password=REDACTED&sslmode=require", redacted.Database.DSN)
+
+	// the original is not modified
+	assert.Equal(t, "secret", cfg.Database.Password)
+	assert.False(t, strings.Contains(redacted.Database.Password, "secret"))
+}
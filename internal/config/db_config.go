@@ -3,58 +3,66 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
-	"github.com/joho/godotenv"
-
 	_ "github.com/lib/pq"
 )
 
 var dbInstance *sql.DB
 
+// ConnectToDB loads the configuration from the environment and connects,
+// it exits when the database is not reachable
 func ConnectToDB() *sql.DB {
-
-	// load env variables
-	if os.Getenv("ENV") != "production" {
-		cwd, _ := os.Getwd() // current working directory
-		rootPath := findRootEnvPath(cwd)
-		err := godotenv.Load(filepath.Join(rootPath, ".env"))
-		if err != nil {
-			log.Fatal("Error loading .env file")
-		}
+	cfg, err := Load(nil)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
 	}
 
-	// get credentials from .env
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	psqlInfo := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
+	db, err := Connect(cfg.Database)
+	if err != nil {
+		slog.Error("Database connection failed", "error", err)
+		os.Exit(1)
+	}
+	return db
+}
 
-	db, err := sql.Open("postgres", psqlInfo)
+// Connect opens the connection pool, checks it and sets it as the global database
+func Connect(cfg DBConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.ConnString())
 	if err != nil {
-		log.Fatalf("Cannot establish connection: %v", err)
+		return nil, fmt.Errorf("cannot establish connection: %w", err)
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	err = db.Ping()
 	if err != nil {
-		log.Fatalf("Cannot connect to database: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("cannot connect to database: %w", err)
 	}
 
-	fmt.Println("Connected to database")
+	slog.Info("Connected to database", "host", cfg.Host, "database", cfg.Name)
 
 	SetDB(db) // set the global database connection
-	return db
+	return db, nil
 }
 
+// InitSchema runs Migrate and exits when it fails
 func InitSchema(db *sql.DB) {
+	if err := Migrate(db); err != nil {
+		slog.Error("Error creating a table", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Table has been created")
+}
+
+// Migrate creates missing tables and columns, it is safe to run repeatedly
+func Migrate(db *sql.DB) error {
 	query := `
 	CREATE TABLE IF NOT EXISTS banks (
 		id SERIAL PRIMARY KEY,
@@ -63,13 +71,156 @@ func InitSchema(db *sql.DB) {
 		country_code VARCHAR(2),
 		country_name TEXT,
 		is_headquarter BOOLEAN,
-		swift_code VARCHAR(11) UNIQUE
+		swift_code VARCHAR(11) UNIQUE,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		town_name TEXT,
+		latitude DOUBLE PRECISION,
+		longitude DOUBLE PRECISION,
+		time_zone TEXT
+	);
+
+	-- databases created before updated_at was added
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+	-- town and its coordinates from the gazetteer, NULL when unknown
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS town_name TEXT;
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+	-- distance searches narrow down to a bounding box with this index
+	CREATE INDEX IF NOT EXISTS banks_location ON banks (latitude, longitude) WHERE latitude IS NOT NULL;
+
+	-- IANA time zone of the bank, NULL when unknown
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS time_zone TEXT;
+
+	-- changes not visible in banks.updated_at (deleted rows)
+	CREATE TABLE IF NOT EXISTS country_updates (
+		country_code VARCHAR(2) PRIMARY KEY,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	-- change log, written in the same transaction as the change (outbox)
+	CREATE TABLE IF NOT EXISTS events (
+		id BIGSERIAL PRIMARY KEY,
+		type TEXT NOT NULL,
+		swift_code VARCHAR(11),
+		country_code VARCHAR(2),
+		payload JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	-- old events are deleted by age (EVENTS_RETENTION)
+	CREATE INDEX IF NOT EXISTS events_created ON events (created_at);
+
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id BIGSERIAL PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT[] NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	-- one row per event and matching subscription, created together with the event
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+		event_id BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription ON webhook_deliveries (subscription_id, id);
+
+	CREATE TABLE IF NOT EXISTS webhook_attempts (
+		id BIGSERIAL PRIMARY KEY,
+		delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+		attempted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		status_code INT,
+		error TEXT,
+		duration_ms BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS webhook_attempts_delivery ON webhook_attempts (delivery_id);
+
+	-- national identifiers (sort codes, Bankleitzahl, ...) of directory entries
+	CREATE TABLE IF NOT EXISTS bank_identifiers (
+		scheme TEXT NOT NULL,
+		identifier TEXT NOT NULL,
+		bank_id INT NOT NULL REFERENCES banks (id) ON DELETE CASCADE,
+		PRIMARY KEY (scheme, identifier)
+	);
+	CREATE INDEX IF NOT EXISTS bank_identifiers_bank ON bank_identifiers (bank_id);
+
+	-- bank holidays per country of banks.country_code, banks are closed all day
+	CREATE TABLE IF NOT EXISTS holidays (
+		country_code VARCHAR(2) NOT NULL,
+		date DATE NOT NULL,
+		name TEXT NOT NULL,
+		PRIMARY KEY (country_code, date)
+	);
+
+	-- last sanctions screening of the bank, it is stale when the bank changed afterwards
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS screened_at TIMESTAMPTZ;
+
+	-- entries of imported sanctions lists, an import replaces the entries of its list
+	CREATE TABLE IF NOT EXISTS sanction_entries (
+		list TEXT NOT NULL,
+		entry_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		aliases TEXT[] NOT NULL DEFAULT '{}',
+		countries TEXT[] NOT NULL DEFAULT '{}',
+		program TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (list, entry_id)
+	);
+
+	-- banks similar to a sanctions list entry, reviewed by accepting or dismissing them
+	CREATE TABLE IF NOT EXISTS screening_hits (
+		id BIGSERIAL PRIMARY KEY,
+		bank_id INT NOT NULL REFERENCES banks (id) ON DELETE CASCADE,
+		list TEXT NOT NULL,
+		entry_id TEXT NOT NULL,
+		entry_name TEXT NOT NULL,
+		matched_name TEXT NOT NULL,
+		program TEXT NOT NULL DEFAULT '',
+		bank_name TEXT NOT NULL,
+		score DOUBLE PRECISION NOT NULL,
+		status TEXT NOT NULL DEFAULT 'open',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		UNIQUE (bank_id, list, entry_id)
+	);
+	CREATE INDEX IF NOT EXISTS screening_hits_status ON screening_hits (status, id);
+
+	-- audit log of the reviews, kept when the bank or the hit is deleted
+	CREATE TABLE IF NOT EXISTS screening_decisions (
+		id BIGSERIAL PRIMARY KEY,
+		hit_id BIGINT NOT NULL,
+		swift_code VARCHAR(11) NOT NULL,
+		list TEXT NOT NULL,
+		entry_id TEXT NOT NULL,
+		bank_name TEXT NOT NULL,
+		matched_name TEXT NOT NULL,
+		score DOUBLE PRECISION NOT NULL,
+		decision TEXT NOT NULL,
+		reviewer TEXT NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		decided_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS screening_decisions_hit ON screening_decisions (hit_id, id);
+
+	-- structured parts of banks.address (street, building number, post code, town and
+	-- subdivision), NULL when none were recognised
+	ALTER TABLE banks ADD COLUMN IF NOT EXISTS postal_address JSONB;
+
+	-- seed imports, completed_at stays NULL until the whole file was imported so an
+	-- interrupted seed is resumed instead of skipped because banks is no longer empty
+	CREATE TABLE IF NOT EXISTS seeds (
+		id BIGSERIAL PRIMARY KEY,
+		file TEXT NOT NULL,
+		started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		completed_at TIMESTAMPTZ
 	);`
 	_, err := db.Exec(query)
-	if err != nil {
-		log.Fatalf("Error creating a table: %v", err)
-	}
-	fmt.Println("Table has been created")
+	return err
 }
 
 func GetDB() *sql.DB {
@@ -81,6 +232,7 @@ func SetDB(database *sql.DB) {
 	dbInstance = database
 }
 
+// returns "" when there is no .env file in any parent directory
 func findRootEnvPath(startPath string) string {
 	current := startPath
 	for {
@@ -89,7 +241,7 @@ func findRootEnvPath(startPath string) string {
 		}
 		parent := filepath.Dir(current)
 		if parent == current {
-			log.Fatal(".env file not found in any parent directories")
+			return ""
 		}
 		current = parent
 	}
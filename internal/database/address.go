@@ -0,0 +1,34 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/white67/swift_api/internal/address"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// splits the address of a bank into its postal address parts, after locate so
+// that the normalized town is matched. Any postal address given by the caller is replaced.
+func structure(b *model.Bank) {
+	b.PostalAddress = address.Parse(b.CountryCode, b.Address, b.TownName)
+}
+
+// the postal_address column, NULL for a bank without one
+func postalAddressJSON(a *model.PostalAddress) any {
+	if a == nil {
+		return nil
+	}
+	data, _ := json.Marshal(a) // strings only, cannot fail
+	return string(data)
+}
+
+func postalAddress(data []byte) *model.PostalAddress {
+	if data == nil {
+		return nil
+	}
+	var a model.PostalAddress
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil
+	}
+	return &a
+}
@@ -1,13 +1,18 @@
 package database_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/white67/swift_api/internal/config"
 	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/geo"
 	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+	"github.com/white67/swift_api/internal/screening"
 )
 
 var testDB *sql.DB
@@ -181,3 +186,347 @@ func TestGetBranchesForHeadquarter(t *testing.T) {
 	assert.NoError(t, err, "Should not error when inserting other bank")
 
 }
+
+func TestImportIdentifiers(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	for _, code := range []string{"TESTGB2LXXX", "TESTGB2L123"} {
+		err := database.InsertBank(testDB, model.Bank{
+			Address: "Test Address", Name: "Test Bank", CountryCode: "GB", CountryName: "UNITED KINGDOM",
+			IsHeadquarter: model.TypeHeadquarters(code), SwiftCode: code,
+		})
+		assert.NoError(t, err)
+	}
+
+	unmatched, failed, err := database.ImportIdentifiersContext(ctx, testDB, []model.BankIdentifier{
+		{Scheme: model.SchemeSortCode, Identifier: "200000", SwiftCode: "TESTGB2LXXX"},
+		{Scheme: model.SchemeSortCode, Identifier: "200001", SwiftCode: "TESTGB2L123"},
+		{Scheme: model.SchemeSortCode, Identifier: "200002", SwiftCode: "MISSGB2LXXX"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, unmatched, "Identifiers of unknown SWIFT codes should not be linked")
+	assert.Equal(t, 0, failed)
+
+	bank, err := database.GetBankByIdentifierContext(ctx, testDB, model.SchemeSortCode, "200001")
+	assert.NoError(t, err)
+	assert.Equal(t, "TESTGB2L123", bank.SwiftCode)
+	byCode, err := database.GetBankBySwiftCodeContext(ctx, testDB, "TESTGB2L123")
+	assert.NoError(t, err)
+	assert.Equal(t, byCode, bank, "The bank should look the same as on a SWIFT code lookup")
+
+	// a second import moves the identifier
+	_, _, err = database.ImportIdentifiersContext(ctx, testDB, []model.BankIdentifier{
+		{Scheme: model.SchemeSortCode, Identifier: "200001", SwiftCode: "TESTGB2LXXX"},
+	})
+	assert.NoError(t, err)
+	bank, err = database.GetBankByIdentifierContext(ctx, testDB, model.SchemeSortCode, "200001")
+	assert.NoError(t, err)
+	assert.Equal(t, "TESTGB2LXXX", bank.SwiftCode)
+
+	// links are removed with their bank
+	_, err = database.DeleteBankContext(ctx, testDB, "TESTGB2LXXX", time.Time{})
+	assert.NoError(t, err)
+	_, err = database.GetBankByIdentifierContext(ctx, testDB, model.SchemeSortCode, "200000")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestNearbyBanks(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	gazetteer, err := geo.LoadGazetteer("../../data/gazetteer.csv")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	database.SetGazetteer(gazetteer)
+	defer database.SetGazetteer(nil)
+
+	ctx := context.Background()
+	for _, b := range []model.Bank{
+		{SwiftCode: "TESTPLPWXXX", TownName: "WARSZAWA", CountryCode: "PL"},
+		{SwiftCode: "TESTPLPW001", TownName: "Pultusk", CountryCode: "PL"}, // 52 km north
+		{SwiftCode: "TESTPLPW002", TownName: "KRAKOW", CountryCode: "PL"},
+		{SwiftCode: "TESTPLPW003", TownName: "NOWHERE", CountryCode: "PL"},
+	} {
+		b.Name, b.Address, b.CountryName = "Test Bank", "Test Address", "POLAND"
+		assert.NoError(t, database.InsertBankContext(ctx, testDB, b))
+	}
+
+	bank, err := database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPW001")
+	assert.NoError(t, err)
+	assert.Equal(t, "PULTUSK", bank.TownName)
+	if assert.NotNil(t, bank.Location, "Towns in the gazetteer should be geocoded") {
+		assert.InDelta(t, 52.70, bank.Location.Latitude, 0.01)
+	}
+	bank, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPW003")
+	assert.NoError(t, err)
+	assert.Nil(t, bank.Location)
+
+	warsaw, _ := gazetteer.Lookup("PL", "WARSZAWA")
+	banks, err := database.NearbyBanksContext(ctx, testDB, warsaw, 100, "", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, banks, 2) {
+		assert.Equal(t, "TESTPLPWXXX", banks[0].SwiftCode)
+		assert.InDelta(t, 0, banks[0].DistanceKm, 0.001)
+		assert.Equal(t, "TESTPLPW001", banks[1].SwiftCode)
+		assert.InDelta(t, geo.Distance(warsaw, *banks[1].Location), banks[1].DistanceKm, 0.001)
+	}
+
+	banks, err = database.NearbyBanksContext(ctx, testDB, warsaw, 100, "DE", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, banks)
+}
+
+func TestBankTimeZone(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	bank := model.Bank{SwiftCode: "TESTPLPWXXX", Name: "Test Bank", Address: "Test Address", CountryCode: "PL", CountryName: "POLAND"}
+	assert.NoError(t, database.InsertBankContext(ctx, testDB, bank))
+
+	stored, err := database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Empty(t, stored.TimeZone)
+	inserted := stored.UpdatedAt
+
+	var lastEvent int64
+	assert.NoError(t, testDB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM events").Scan(&lastEvent))
+
+	// inserting again keeps the stored row
+	bank.TimeZone = "Europe/Warsaw"
+	assert.NoError(t, database.InsertBankContext(ctx, testDB, bank))
+	stored, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Empty(t, stored.TimeZone)
+	assert.Equal(t, inserted, stored.UpdatedAt)
+
+	// importing again fills in the missing zone, a change like any other
+	assert.NoError(t, database.UpsertBankContext(ctx, testDB, bank))
+	stored, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Warsaw", stored.TimeZone)
+	assert.True(t, stored.UpdatedAt.After(inserted), "Filling in a row sets updated_at")
+
+	var eventType, payload string
+	err = testDB.QueryRow("SELECT type, payload::text FROM events WHERE id > $1 AND swift_code = 'TESTPLPWXXX'", lastEvent).Scan(&eventType, &payload)
+	assert.NoError(t, err)
+	assert.Equal(t, model.EventUpdated, eventType)
+	assert.Contains(t, payload, "Europe/Warsaw")
+
+	// importing an unchanged row changes nothing
+	assert.NoError(t, database.UpsertBankContext(ctx, testDB, bank))
+	var events int
+	assert.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM events WHERE id > $1 AND swift_code = 'TESTPLPWXXX'", lastEvent).Scan(&events))
+	assert.Equal(t, 1, events)
+
+	// an update without a zone keeps it
+	bank.TimeZone = ""
+	_, err = database.UpdateBankContext(ctx, testDB, bank, time.Time{})
+	assert.NoError(t, err)
+	stored, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Warsaw", stored.TimeZone)
+}
+
+func TestHolidays(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+	defer testDB.Exec("DELETE FROM holidays")
+
+	ctx := context.Background()
+	holidays, err := database.SeedHolidaysIfEmpty(ctx, testDB, "../../data/holidays.csv", parser.ParseHolidayCSV)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, holidays)
+
+	holidays, err = database.SeedHolidaysIfEmpty(ctx, testDB, "../../data/holidays.csv", parser.ParseHolidayCSV)
+	assert.NoError(t, err)
+	assert.Nil(t, holidays, "Only an empty table is seeded")
+
+	// a second import of a date replaces the name
+	err = database.ImportHolidaysContext(ctx, testDB, []model.Holiday{{CountryCode: "pl", Date: "2026-11-11", Name: "Narodowe Swieto Niepodleglosci"}})
+	assert.NoError(t, err)
+
+	from, _ := time.Parse(model.DateLayout, "2026-11-01")
+	to, _ := time.Parse(model.DateLayout, "2026-11-30")
+	holidays, err = database.ListHolidaysContext(ctx, testDB, "PL", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Holiday{
+		{CountryCode: "PL", Date: "2026-11-01", Name: "All Saints' Day"},
+		{CountryCode: "PL", Date: "2026-11-11", Name: "Narodowe Swieto Niepodleglosci"},
+	}, holidays)
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	event := model.Event{ID: 10, SwiftCode: "BPKOPLPWXXX", CountryCode: "PL"}
+
+	assert.True(t, database.EventFilter{}.Matches(event))
+	assert.False(t, database.EventFilter{AfterID: 10}.Matches(event), "Events up to AfterID were sent")
+	assert.True(t, database.EventFilter{CountryCode: "pl"}.Matches(event))
+	assert.False(t, database.EventFilter{CountryCode: "DE"}.Matches(event))
+	assert.True(t, database.EventFilter{SwiftCode: "BPKOPLPW"}.Matches(event), "BIC8 matches the headquarter and its branches")
+	assert.True(t, database.EventFilter{SwiftCode: "BPKOPLPWXXX"}.Matches(event))
+	assert.False(t, database.EventFilter{SwiftCode: "BPKOPLPW001"}.Matches(event))
+}
+
+func TestSeedIfEmpty(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+	defer testDB.Exec("DELETE FROM seeds")
+
+	ctx := context.Background()
+	bank := model.Bank{Address: "Test Address", Name: "Test Bank", CountryCode: "US", CountryName: "UNITED STATES", IsHeadquarter: true, SwiftCode: "TESTUS1XXXX"}
+	assert.NoError(t, database.InsertBankContext(ctx, testDB, bank))
+
+	// data that was not seeded is left alone
+	banks, err := database.SeedIfEmpty(ctx, testDB, "../../data/2025_SWIFT_CODES.csv", parser.ParseSwiftCSV)
+	assert.NoError(t, err)
+	assert.Nil(t, banks)
+
+	// a seed stopped halfway is resumed although banks is not empty
+	_, err = testDB.Exec("INSERT INTO seeds (file) VALUES ('../../data/2025_SWIFT_CODES.csv')")
+	assert.NoError(t, err)
+	banks, err = database.SeedIfEmpty(ctx, testDB, "../../data/2025_SWIFT_CODES.csv", parser.ParseSwiftCSV)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, banks)
+
+	var open int
+	assert.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM seeds WHERE completed_at IS NULL").Scan(&open))
+	assert.Zero(t, open, "The seed is marked complete")
+
+	banks, err = database.SeedIfEmpty(ctx, testDB, "../../data/2025_SWIFT_CODES.csv", parser.ParseSwiftCSV)
+	assert.NoError(t, err)
+	assert.Nil(t, banks, "A completed seed is not repeated")
+}
+
+func TestScreening(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+	defer testDB.Exec("DELETE FROM sanction_entries")
+	defer testDB.Exec("DELETE FROM screening_decisions")
+
+	ctx := context.Background()
+	for _, b := range []model.Bank{
+		{SwiftCode: "MELIDEHHXXX", Name: "BANK MELLI IRAN", Address: "Hamburg", CountryCode: "DE", CountryName: "GERMANY"},
+		{SwiftCode: "TESTPLPWXXX", Name: "Test Bank", Address: "Warsaw", CountryCode: "PL", CountryName: "POLAND"},
+	} {
+		assert.NoError(t, database.InsertBankContext(ctx, testDB, b))
+	}
+
+	bank, err := database.GetBankBySwiftCodeContext(ctx, testDB, "MELIDEHHXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScreeningNotScreened, bank.ScreeningStatus)
+
+	entries := []model.SanctionEntry{
+		{List: "test", EntryID: "1", Name: "Bank Melli Iran", Countries: []string{"IR", "DE"}, Program: "IRAN"},
+		{List: "test", EntryID: "2", Name: "Obsolete Entry"},
+	}
+	_, err = database.ImportSanctionListContext(ctx, testDB, "test", entries)
+	assert.NoError(t, err)
+	removed, err := database.ImportSanctionListContext(ctx, testDB, "test", entries[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed, "an import replaces the list")
+
+	stored, err := database.ListSanctionEntriesContext(ctx, testDB)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	matcher := screening.NewMatcher(stored, screening.DefaultThreshold)
+	result, err := database.ScreenBanksContext(ctx, testDB, matcher, false)
+	assert.NoError(t, err)
+	assert.Equal(t, database.ScreeningResult{Banks: 2, Hits: 1, NewHits: 1}, result)
+
+	// unchanged banks are not screened again
+	result, err = database.ScreenBanksContext(ctx, testDB, matcher, false)
+	assert.NoError(t, err)
+	assert.Zero(t, result.Banks)
+
+	clear, err := database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScreeningClear, clear.ScreeningStatus)
+	bank, err = database.GetBankBySwiftCodeContext(ctx, testDB, "MELIDEHHXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScreeningPotentialMatch, bank.ScreeningStatus)
+
+	hits, err := database.ListScreeningHitsContext(ctx, testDB, database.ScreeningHitFilter{Status: model.HitOpen, CountryCode: "de"}, 0, 10)
+	assert.NoError(t, err)
+	if !assert.Len(t, hits, 1) {
+		return
+	}
+	assert.Equal(t, "MELIDEHHXXX", hits[0].SwiftCode)
+	assert.Equal(t, "Bank Melli Iran", hits[0].MatchedName)
+	assert.Equal(t, 1.0, hits[0].Score)
+
+	hit, err := database.DecideScreeningHitContext(ctx, testDB, hits[0].ID, model.ScreeningDecision{Decision: model.DecisionAccept, Reviewer: "compliance", Comment: "listed branch"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.HitAccepted, hit.Status)
+	if assert.Len(t, hit.Decisions, 1) {
+		assert.Equal(t, "compliance", hit.Decisions[0].Reviewer)
+	}
+	potential := bank
+	bank, err = database.GetBankBySwiftCodeContext(ctx, testDB, "MELIDEHHXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScreeningMatch, bank.ScreeningStatus)
+	// the status change is visible to If-Modified-Since without marking the bank unscreened
+	assert.True(t, bank.UpdatedAt.After(potential.UpdatedAt))
+	result, err = database.ScreenBanksContext(ctx, testDB, matcher, false)
+	assert.NoError(t, err)
+	assert.Zero(t, result.Banks)
+
+	// a full run keeps the decision while nothing changed
+	_, err = database.ScreenBanksContext(ctx, testDB, matcher, true)
+	assert.NoError(t, err)
+	hit, err = database.GetScreeningHitContext(ctx, testDB, hit.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.HitAccepted, hit.Status)
+
+	_, err = database.DecideScreeningHitContext(ctx, testDB, hit.ID+1000, model.ScreeningDecision{Decision: model.DecisionDismiss, Reviewer: "compliance"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the audit log outlives the bank
+	_, err = database.DeleteBankContext(ctx, testDB, "MELIDEHHXXX", time.Time{})
+	assert.NoError(t, err)
+	var decisions int
+	assert.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM screening_decisions WHERE swift_code = 'MELIDEHHXXX'").Scan(&decisions))
+	assert.Equal(t, 1, decisions)
+}
+
+func TestBankPostalAddress(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	bank := model.Bank{
+		SwiftCode: "TESTPLPWXXX", Name: "Test Bank", Address: "UL. WRONIA 31  WARSZAWA, MAZOWIECKIE, 00-846",
+		TownName: "WARSZAWA", CountryCode: "PL", CountryName: "POLAND",
+	}
+	assert.NoError(t, database.InsertBankContext(ctx, testDB, bank))
+
+	stored, err := database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, bank.Address, stored.Address)
+	assert.Equal(t, &model.PostalAddress{
+		StreetName: "UL. WRONIA", BuildingNumber: "31", PostCode: "00-846", TownName: "WARSZAWA", CountrySubDivision: "MAZOWIECKIE",
+	}, stored.PostalAddress)
+
+	// an update parses the new address
+	bank.Address = "UL. PROSTA 18  WARSZAWA, MAZOWIECKIE, 00-850"
+	_, err = database.UpdateBankContext(ctx, testDB, bank, time.Time{})
+	assert.NoError(t, err)
+	stored, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPWXXX")
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.PostalAddress) {
+		assert.Equal(t, "UL. PROSTA", stored.PostalAddress.StreetName)
+		assert.Equal(t, "18", stored.PostalAddress.BuildingNumber)
+		assert.Equal(t, "00-850", stored.PostalAddress.PostCode)
+	}
+
+	// nothing to recognise
+	bank.SwiftCode, bank.Address, bank.TownName = "TESTPLPW001", "", ""
+	assert.NoError(t, database.InsertBankContext(ctx, testDB, bank))
+	stored, err = database.GetBankBySwiftCodeContext(ctx, testDB, "TESTPLPW001")
+	assert.NoError(t, err)
+	assert.Nil(t, stored.PostalAddress)
+}
@@ -1,14 +1,34 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/lib/pq"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/metrics"
 	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func InsertBank(db *sql.DB, b model.Bank) error {
+	return InsertBankContext(context.Background(), db, b)
+}
+
+// inserts a bank unless its SWIFT code is stored, errors are logged with the request ID
+func InsertBankContext(ctx context.Context, db *sql.DB, b model.Bank) (err error) {
+	ctx, done := observe(ctx, "InsertBank", &err)
+	defer done()
+
+	locate(&b)
+	structure(&b)
+	lat, lon := coordinates(b.Location)
+
 	query := `
 	INSERT INTO banks (
 		address,
@@ -16,40 +36,206 @@ func InsertBank(db *sql.DB, b model.Bank) error {
 		country_code,
 		country_name,
 		is_headquarter,
-		swift_code
-	) VALUES ($1, $2, $3, $4, $5, $6)
+		swift_code,
+		town_name,
+		latitude,
+		longitude,
+		time_zone,
+		postal_address
+	) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11)
 	ON CONFLICT (swift_code) DO NOTHING;`
 
-	_, err := db.Exec(query,
+	_, err = db.ExecContext(ctx, query,
 		b.Address,
 		b.Name,
 		strings.ToUpper(b.CountryCode), // instead of b.CountryCode
 		strings.ToUpper(b.CountryName), // instead of b.CountryName
 		b.IsHeadquarter,
 		strings.ToUpper(b.SwiftCode), // instead of b.SwiftCode
+		b.TownName,
+		lat,
+		lon,
+		b.TimeZone,
+		postalAddressJSON(b.PostalAddress),
 	)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when inserting new data",
+			"swift_code", b.SwiftCode,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// inserts a bank, of a stored one only a missing town, location, time zone or postal
+// address is filled in. Errors are logged with the request ID.
+func UpsertBankContext(ctx context.Context, db *sql.DB, b model.Bank) (err error) {
+	ctx, done := observe(ctx, "UpsertBank", &err)
+	defer done()
+
+	locate(&b)
+	structure(&b)
+	lat, lon := coordinates(b.Location)
 
+	// existing rows are kept, only a missing town, location, time zone or postal address
+	// is filled in (databases imported before they were stored, towns added to the gazetteer).
+	// A filled in row gets a new updated_at and an updated event like any other change;
+	// xmax is 0 only for freshly inserted rows, rows left unchanged are not returned.
+	query := `
+	INSERT INTO banks (
+		address,
+		bank_name,
+		country_code,
+		country_name,
+		is_headquarter,
+		swift_code,
+		town_name,
+		latitude,
+		longitude,
+		time_zone,
+		postal_address
+	) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11)
+	ON CONFLICT (swift_code) DO UPDATE SET
+		town_name = COALESCE(banks.town_name, EXCLUDED.town_name),
+		latitude = CASE WHEN banks.latitude IS NULL AND COALESCE(banks.town_name, EXCLUDED.town_name) = EXCLUDED.town_name
+			THEN EXCLUDED.latitude ELSE banks.latitude END,
+		longitude = CASE WHEN banks.latitude IS NULL AND COALESCE(banks.town_name, EXCLUDED.town_name) = EXCLUDED.town_name
+			THEN EXCLUDED.longitude ELSE banks.longitude END,
+		time_zone = COALESCE(banks.time_zone, EXCLUDED.time_zone),
+		postal_address = CASE WHEN banks.postal_address IS NULL AND banks.address = EXCLUDED.address
+			THEN EXCLUDED.postal_address ELSE banks.postal_address END,
+		updated_at = now()
+	WHERE (EXCLUDED.town_name IS NOT NULL
+			AND (banks.town_name IS NULL
+				OR (banks.town_name = EXCLUDED.town_name AND banks.latitude IS NULL AND EXCLUDED.latitude IS NOT NULL)))
+		OR (banks.time_zone IS NULL AND EXCLUDED.time_zone IS NOT NULL)
+		OR (banks.postal_address IS NULL AND banks.address = EXCLUDED.address AND EXCLUDED.postal_address IS NOT NULL)
+	RETURNING xmax = 0, bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at,
+		COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address;`
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		var inserted bool
+		var stored model.Bank
+		var storedLat, storedLon sql.NullFloat64
+		var postal []byte
+		err := tx.QueryRowContext(ctx, query,
+			b.Address,
+			b.Name,
+			strings.ToUpper(b.CountryCode), // instead of b.CountryCode
+			strings.ToUpper(b.CountryName), // instead of b.CountryName
+			b.IsHeadquarter,
+			strings.ToUpper(b.SwiftCode), // instead of b.SwiftCode
+			b.TownName,
+			lat,
+			lon,
+			b.TimeZone,
+			postalAddressJSON(b.PostalAddress),
+		).Scan(&inserted, &stored.Name, &stored.Address, &stored.CountryCode, &stored.CountryName, &stored.SwiftCode,
+			&stored.IsHeadquarter, &stored.UpdatedAt, &stored.TownName, &storedLat, &storedLon, &stored.TimeZone, &postal)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && inserted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		stored.Location = location(storedLat, storedLon)
+		stored.PostalAddress = postalAddress(postal)
+		return insertEvent(ctx, tx, model.EventUpdated, stored.SwiftCode, stored.CountryCode, stored)
+	})
 	if err != nil {
-		log.Printf("Error when inserting new data: %v", err)
+		logging.FromContext(ctx).Error("Error when inserting new data",
+			"swift_code", b.SwiftCode,
+			"error", err,
+		)
 		return err
 	}
 
 	return nil
 }
 
-func InsertAllBanks(db *sql.DB, banks []model.Bank) error {
-	for _, bank := range banks {
-		err := InsertBank(db, bank)
+// returned by CreateBankContext when the SWIFT code is already stored
+var ErrDuplicate = errors.New("swift code already exists")
+
+// inserts a new bank and records a created event,
+// unlike InsertBankContext an existing SWIFT code is an error (ErrDuplicate)
+func CreateBankContext(ctx context.Context, db *sql.DB, b model.Bank) (err error) {
+	ctx, done := observe(ctx, "CreateBank", &err)
+	defer done()
+
+	locate(&b)
+	structure(&b)
+	lat, lon := coordinates(b.Location)
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO banks (address, bank_name, country_code, country_name, is_headquarter, swift_code, town_name, latitude, longitude, time_zone, postal_address)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11)`,
+			b.Address,
+			b.Name,
+			b.CountryCode,
+			b.CountryName,
+			b.IsHeadquarter,
+			b.SwiftCode,
+			b.TownName,
+			lat,
+			lon,
+			b.TimeZone,
+			postalAddressJSON(b.PostalAddress),
+		)
 		if err != nil {
-			log.Printf("Error when inserting new data%s: %v", bank.SwiftCode, err)
+			return err
 		}
+		return insertEvent(ctx, tx, model.EventCreated, b.SwiftCode, b.CountryCode, b)
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrDuplicate
+	}
+	return err
+}
+
+func InsertAllBanks(db *sql.DB, banks []model.Bank) error {
+	for _, bank := range banks {
+		// failed rows are logged by InsertBankContext
+		InsertBankContext(context.Background(), db, bank)
 	}
 	return nil
 }
 
+// inserts new banks and keeps existing ones (only their missing town, location, time zone
+// or postal address is filled in), rows that fail are logged and counted but do not stop the import
+func ImportBanks(ctx context.Context, db *sql.DB, banks []model.Bank) (failed int, err error) {
+	start := time.Now()
+	for _, bank := range banks {
+		if err := ctx.Err(); err != nil {
+			return failed, err
+		}
+		if err := UpsertBankContext(ctx, db, bank); err != nil {
+			failed++
+		}
+	}
+	metrics.ObserveImport(len(banks)-failed, failed, time.Since(start))
+	slog.Info("Import finished", "rows", len(banks), "failed", failed, "duration", time.Since(start))
+
+	summary := importSummary{Rows: len(banks), Imported: len(banks) - failed, Failed: failed}
+	if err := RecordEventContext(ctx, db, model.EventImportCompleted, "", "", summary); err != nil {
+		slog.Error("Error when recording the import event", "error", err)
+	}
+	return failed, nil
+}
+
 func IsDatabaseEmpty(db *sql.DB) (bool, error) {
+	return IsDatabaseEmptyContext(context.Background(), db)
+}
+
+func IsDatabaseEmptyContext(ctx context.Context, db *sql.DB) (empty bool, err error) {
+	ctx, done := observe(ctx, "IsDatabaseEmpty", &err)
+	defer done()
+
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM banks").Scan(&count)
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM banks").Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -57,31 +243,410 @@ func IsDatabaseEmpty(db *sql.DB) (bool, error) {
 }
 
 func GetBankBySwiftCode(db *sql.DB, swiftCode string) (*model.Bank, error) {
-	row := db.QueryRow("SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter FROM banks WHERE swift_code = $1", swiftCode)
+	return GetBankBySwiftCodeContext(context.Background(), db, swiftCode)
+}
+
+func GetBankBySwiftCodeContext(ctx context.Context, db *sql.DB, swiftCode string) (bank *model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBankBySwiftCode", &err)
+	defer done()
+
+	row := db.QueryRowContext(ctx, "SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at, COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address, "+screeningStatus+" FROM banks WHERE swift_code = $1", swiftCode)
 
 	var b model.Bank
-	err := row.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter)
+	var lat, lon sql.NullFloat64
+	var postal []byte
+	err = row.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt, &b.TownName, &lat, &lon, &b.TimeZone, &postal, &b.ScreeningStatus)
 	if err != nil {
 		return nil, err
 	}
+	b.Location = location(lat, lon)
+	b.PostalAddress = postalAddress(postal)
 	return &b, nil
 }
 
 func GetBranchesForHeadquarter(db *sql.DB, hqSwift string) ([]model.Bank, error) {
-	rows, err := db.Query("SELECT bank_name, address, country_code, swift_code, is_headquarter FROM banks WHERE swift_code LIKE $1 AND swift_code != $2", hqSwift[:8]+"%", hqSwift)
+	return GetBranchesForHeadquarterContext(context.Background(), db, hqSwift)
+}
+
+func GetBranchesForHeadquarterContext(ctx context.Context, db *sql.DB, hqSwift string) (branches []model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBranchesForHeadquarter", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, "SELECT bank_name, address, country_code, swift_code, is_headquarter, updated_at, COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address, "+screeningStatus+" FROM banks WHERE swift_code LIKE $1 AND swift_code != $2", hqSwift[:8]+"%", hqSwift)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var branches []model.Bank
 	for rows.Next() {
 		var b model.Bank
-		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.SwiftCode, &b.IsHeadquarter)
+		var lat, lon sql.NullFloat64
+		var postal []byte
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt, &b.TownName, &lat, &lon, &b.TimeZone, &postal, &b.ScreeningStatus)
 		if err != nil {
 			return nil, err
 		}
+		b.Location = location(lat, lon)
+		b.PostalAddress = postalAddress(postal)
 		branches = append(branches, b)
 	}
 	return branches, nil
 }
+
+// branches of many headquarters with a single query, ordered by SWIFT code
+func GetBranchesForHeadquartersContext(ctx context.Context, db *sql.DB, hqSwiftCodes []string) (branches []model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBranchesForHeadquarters", &err)
+	defer done()
+
+	prefixes := make([]string, 0, len(hqSwiftCodes))
+	for _, code := range hqSwiftCodes {
+		if len(code) >= 8 {
+			prefixes = append(prefixes, code[:8])
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, is_headquarter, swift_code, updated_at
+	FROM banks
+	WHERE LEFT(swift_code, 8) = ANY($1) AND swift_code NOT LIKE '%XXX'
+	ORDER BY swift_code`, pq.Array(prefixes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.IsHeadquarter, &b.SwiftCode, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, b)
+	}
+	return branches, rows.Err()
+}
+
+// one page of a country's banks ordered by SWIFT code, starting after the given code (keyset pagination)
+func GetBanksByCountryPageContext(ctx context.Context, db *sql.DB, countryCode, after string, limit int) (banks []model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBanksByCountryPage", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, is_headquarter, swift_code, updated_at
+	FROM banks
+	WHERE country_code = $1 AND swift_code > $2
+	ORDER BY swift_code
+	LIMIT $3`, countryCode, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.IsHeadquarter, &b.SwiftCode, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, b)
+	}
+	return banks, rows.Err()
+}
+
+// name and number of banks of a country, sql.ErrNoRows if it has none
+func GetCountrySummaryContext(ctx context.Context, db *sql.DB, countryCode string) (name string, count int, err error) {
+	ctx, done := observe(ctx, "GetCountrySummary", &err)
+	defer done()
+
+	err = db.QueryRowContext(ctx, `
+	SELECT MAX(country_name), COUNT(*)
+	FROM banks
+	WHERE country_code = $1
+	HAVING COUNT(*) > 0`, countryCode).Scan(&name, &count)
+	return name, count, err
+}
+
+// all banks of a country, ordered by SWIFT code
+func GetBanksByCountryContext(ctx context.Context, db *sql.DB, countryCode string) (banks []model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBanksByCountry", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, is_headquarter, swift_code, updated_at
+	FROM banks
+	WHERE country_code = $1
+	ORDER BY swift_code`, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.IsHeadquarter, &b.SwiftCode, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, b)
+	}
+	return banks, rows.Err()
+}
+
+// case-insensitive match on SWIFT code, bank name or address, optionally within one country
+func SearchBanksContext(ctx context.Context, db *sql.DB, query, countryCode string, limit int) (banks []model.Bank, err error) {
+	ctx, done := observe(ctx, "SearchBanks", &err)
+	defer done()
+
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, is_headquarter, swift_code, updated_at
+	FROM banks
+	WHERE (swift_code ILIKE $1 OR bank_name ILIKE $1 OR address ILIKE $1)
+	AND ($2 = '' OR country_code = $2)
+	ORDER BY swift_code
+	LIMIT $3`, pattern, strings.ToUpper(countryCode), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.IsHeadquarter, &b.SwiftCode, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, b)
+	}
+	return banks, rows.Err()
+}
+
+func GetBanksBySwiftCodes(db *sql.DB, swiftCodes []string) ([]model.Bank, error) {
+	return GetBanksBySwiftCodesContext(context.Background(), db, swiftCodes)
+}
+
+// resolves many codes with a single query, codes that do not exist are simply missing
+func GetBanksBySwiftCodesContext(ctx context.Context, db *sql.DB, swiftCodes []string) (banks []model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBanksBySwiftCodes", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at, `+screeningStatus+`
+	FROM banks
+	WHERE swift_code = ANY($1)`, pq.Array(swiftCodes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt, &b.ScreeningStatus)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, b)
+	}
+	return banks, rows.Err()
+}
+
+// calls fn for every bank (optionally only one country) while reading from the cursor,
+// so the whole directory is never held in memory
+func StreamBanks(ctx context.Context, db *sql.DB, countryCode string, fn func(model.Bank) error) (err error) {
+	ctx, done := observe(ctx, "StreamBanks", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at, COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address
+	FROM banks
+	WHERE ($1 = '' OR country_code = $1)
+	ORDER BY swift_code`, strings.ToUpper(countryCode))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.Bank
+		var lat, lon sql.NullFloat64
+		var postal []byte
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt, &b.TownName, &lat, &lon, &b.TimeZone, &postal)
+		if err != nil {
+			return err
+		}
+		b.Location = location(lat, lon)
+		b.PostalAddress = postalAddress(postal)
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func UpdateBank(db *sql.DB, b model.Bank, version time.Time) (int64, error) {
+	return UpdateBankContext(context.Background(), db, b, version)
+}
+
+// updates every field of an existing bank and records an updated event, returns the
+// number of updated rows. A non-zero version only updates the row if its updated_at is unchanged.
+func UpdateBankContext(ctx context.Context, db *sql.DB, b model.Bank, version time.Time) (updated int64, err error) {
+	ctx, done := observe(ctx, "UpdateBank", &err)
+	defer done()
+
+	b.CountryCode = strings.ToUpper(b.CountryCode)
+	b.CountryName = strings.ToUpper(b.CountryName)
+	locate(&b)
+	structure(&b)
+	lat, lon := coordinates(b.Location)
+
+	// without a town the stored town and location are kept, likewise the time zone.
+	// The postal address is always parsed again from the new address.
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+		UPDATE banks SET
+			address = $1,
+			bank_name = $2,
+			country_code = $3,
+			country_name = $4,
+			is_headquarter = $5,
+			town_name = COALESCE(NULLIF($8, ''), town_name),
+			latitude = CASE WHEN $8 = '' THEN latitude ELSE $9 END,
+			longitude = CASE WHEN $8 = '' THEN longitude ELSE $10 END,
+			time_zone = COALESCE(NULLIF($11, ''), time_zone),
+			postal_address = $12,
+			updated_at = now()
+		WHERE swift_code = $6 AND ($7::timestamptz IS NULL OR updated_at = $7);`,
+			b.Address,
+			b.Name,
+			b.CountryCode,
+			b.CountryName,
+			b.IsHeadquarter,
+			b.SwiftCode,
+			nullTime(version),
+			b.TownName,
+			lat,
+			lon,
+			b.TimeZone,
+			postalAddressJSON(b.PostalAddress),
+		)
+		if err != nil {
+			return err
+		}
+		if updated, err = result.RowsAffected(); err != nil || updated == 0 {
+			return err
+		}
+		return insertEvent(ctx, tx, model.EventUpdated, b.SwiftCode, b.CountryCode, b)
+	})
+	return updated, err
+}
+
+func DeleteBank(db *sql.DB, swiftCode string, version time.Time) (string, error) {
+	return DeleteBankContext(context.Background(), db, swiftCode, version)
+}
+
+// deletes a bank, records a deleted event and returns its country code, sql.ErrNoRows if nothing was deleted.
+// A non-zero version only deletes the row if its updated_at is unchanged.
+func DeleteBankContext(ctx context.Context, db *sql.DB, swiftCode string, version time.Time) (countryCode string, err error) {
+	ctx, done := observe(ctx, "DeleteBank", &err)
+	defer done()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+		DELETE FROM banks
+		WHERE swift_code = $1 AND ($2::timestamptz IS NULL OR updated_at = $2)
+		RETURNING country_code;`, swiftCode, nullTime(version)).Scan(&countryCode)
+		if err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, model.EventDeleted, swiftCode, countryCode, deletedBank{SwiftCode: swiftCode, CountryCode: countryCode})
+	})
+	return countryCode, err
+}
+
+func TouchCountry(db *sql.DB, countryCode string) error {
+	return TouchCountryContext(context.Background(), db, countryCode)
+}
+
+// records a change in a country that is not visible in banks.updated_at, e.g. a deleted row
+func TouchCountryContext(ctx context.Context, db *sql.DB, countryCode string) (err error) {
+	ctx, done := observe(ctx, "TouchCountry", &err)
+	defer done()
+
+	_, err = db.ExecContext(ctx, `
+	INSERT INTO country_updates (country_code, updated_at) VALUES ($1, now())
+	ON CONFLICT (country_code) DO UPDATE SET updated_at = now();`, strings.ToUpper(countryCode))
+	return err
+}
+
+func TouchBank(db *sql.DB, swiftCode string) error {
+	return TouchBankContext(context.Background(), db, swiftCode)
+}
+
+// marks a bank as changed, e.g. a headquarter after one of its branches was deleted
+func TouchBankContext(ctx context.Context, db *sql.DB, swiftCode string) (err error) {
+	ctx, done := observe(ctx, "TouchBank", &err)
+	defer done()
+
+	_, err = db.ExecContext(ctx, "UPDATE banks SET updated_at = now() WHERE swift_code = $1", swiftCode)
+	return err
+}
+
+// deleted rows leave no updated_at behind, so after a delete the country and
+// (for a branch) the headquarter are marked as changed instead
+func TouchDeletedContext(ctx context.Context, db *sql.DB, swiftCode, countryCode string) error {
+	if err := TouchCountryContext(ctx, db, countryCode); err != nil {
+		return err
+	}
+	if len(swiftCode) == 11 && !model.TypeHeadquarters(swiftCode) {
+		return TouchBankContext(ctx, db, swiftCode[:8]+"XXX")
+	}
+	return nil
+}
+
+func GetCountryLastModified(db *sql.DB, countryCode string) (time.Time, error) {
+	return GetCountryLastModifiedContext(context.Background(), db, countryCode)
+}
+
+// last change of any bank in the country, including deletions
+func GetCountryLastModifiedContext(ctx context.Context, db *sql.DB, countryCode string) (lastModified time.Time, err error) {
+	ctx, done := observe(ctx, "GetCountryLastModified", &err)
+	defer done()
+
+	var nullable sql.NullTime
+	err = db.QueryRowContext(ctx, `
+	SELECT GREATEST(
+		(SELECT MAX(updated_at) FROM banks WHERE country_code = $1),
+		(SELECT updated_at FROM country_updates WHERE country_code = $1)
+	)`, countryCode).Scan(&nullable)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return nullable.Time, nil
+}
+
+const tracerName = "github.com/white67/swift_api/internal/database"
+
+// starts a span for a database function, the returned func ends it and records metrics:
+//
+//	ctx, done := observe(ctx, "GetBankBySwiftCode", &err)
+//	defer done()
+func observe(ctx context.Context, function string, err *error) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, tracerName, "database."+function,
+		attribute.String("db.system", "postgresql"),
+		attribute.String("code.function", function),
+	)
+	return ctx, func() {
+		metrics.ObserveQuery(function, start, err)
+		// a missing row is an expected result, not a failed query
+		if err != nil && *err != nil && !errors.Is(*err, sql.ErrNoRows) {
+			tracing.End(span, *err)
+			return
+		}
+		tracing.End(span, nil)
+	}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// runs fn in a transaction, committed when fn returns nil
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// advisory lock held while an event is written
+const eventLockID = 7_301_042
+
+// writes an event and queues a webhook delivery for every subscription of its type,
+// inside the transaction of the change so neither can get lost
+func insertEvent(ctx context.Context, tx *sql.Tx, eventType, swiftCode, countryCode string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// event IDs are handed out in commit order, so readers resuming after an ID
+	// (Last-Event-ID) never miss an event committed later with a lower ID
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", eventLockID); err != nil {
+		return err
+	}
+
+	// the payload is passed as a string, lib/pq would send []byte as bytea
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO events (type, swift_code, country_code, payload)
+	VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+	RETURNING id`, eventType, strings.ToUpper(swiftCode), strings.ToUpper(countryCode), string(payload)).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO webhook_deliveries (subscription_id, event_id)
+	SELECT id, $1 FROM webhook_subscriptions WHERE $2 = ANY(event_types)`, id, eventType)
+	return err
+}
+
+// records an event that is not part of a single change, e.g. a finished import
+func RecordEventContext(ctx context.Context, db *sql.DB, eventType, swiftCode, countryCode string, data any) (err error) {
+	ctx, done := observe(ctx, "RecordEvent", &err)
+	defer done()
+
+	return withTx(ctx, db, func(tx *sql.Tx) error {
+		return insertEvent(ctx, tx, eventType, swiftCode, countryCode, data)
+	})
+}
+
+// payload of deleted events
+type deletedBank struct {
+	SwiftCode   string `json:"swiftCode"`
+	CountryCode string `json:"countryISO2"`
+}
+
+// payload of import-completed events
+type importSummary struct {
+	Rows     int `json:"rows"`
+	Imported int `json:"imported"`
+	Failed   int `json:"failed"`
+}
+
+// EventFilter selects events after an ID, optionally of one country or SWIFT code.
+// An 8 character SwiftCode matches the headquarter and all of its branches.
+type EventFilter struct {
+	AfterID     int64
+	CountryCode string
+	SwiftCode   string
+}
+
+// Matches reports whether an event passes the filter, the same conditions ListEventsContext
+// applies in SQL
+func (f EventFilter) Matches(e model.Event) bool {
+	if e.ID <= f.AfterID {
+		return false
+	}
+	if f.CountryCode != "" && !strings.EqualFold(e.CountryCode, f.CountryCode) {
+		return false
+	}
+	code := strings.ToUpper(f.SwiftCode)
+	switch {
+	case code == "":
+		return true
+	case len(code) == 8:
+		return strings.HasPrefix(e.SwiftCode, code)
+	default:
+		return e.SwiftCode == code
+	}
+}
+
+// up to limit events matching the filter, in ID order
+func ListEventsContext(ctx context.Context, db *sql.DB, filter EventFilter, limit int) (events []model.Event, err error) {
+	ctx, done := observe(ctx, "ListEvents", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT id, type, swift_code, country_code, payload, created_at
+	FROM events
+	WHERE id > $1
+		AND ($2 = '' OR country_code = $2)
+		AND ($3 = '' OR swift_code = $3 OR (LENGTH($3) = 8 AND LEFT(swift_code, 8) = $3))
+	ORDER BY id
+	LIMIT $4`, filter.AfterID, strings.ToUpper(filter.CountryCode), strings.ToUpper(filter.SwiftCode), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.Event
+		var swiftCode, countryCode sql.NullString
+		if err := rows.Scan(&e.ID, &e.Type, &swiftCode, &countryCode, &e.Data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.SwiftCode = swiftCode.String
+		e.CountryCode = countryCode.String
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ID of the newest event, 0 when there is none
+func LatestEventIDContext(ctx context.Context, db *sql.DB) (id int64, err error) {
+	ctx, done := observe(ctx, "LatestEventID", &err)
+	defer done()
+
+	err = db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM events").Scan(&id)
+	return id, err
+}
+
+// deletes events created before the given time, returns the number of deleted events.
+// Events with pending webhook deliveries are kept until the deliveries are done.
+func DeleteEventsBeforeContext(ctx context.Context, db *sql.DB, before time.Time) (deleted int64, err error) {
+	ctx, done := observe(ctx, "DeleteEventsBefore", &err)
+	defer done()
+
+	result, err := db.ExecContext(ctx, `
+	DELETE FROM events
+	WHERE created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = events.id AND d.status = 'pending')`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
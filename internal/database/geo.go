@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/white67/swift_api/internal/geo"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// town coordinates for banks written by this package, nil stores towns without a location
+var gazetteer *geo.Gazetteer
+
+func SetGazetteer(g *geo.Gazetteer) {
+	gazetteer = g
+}
+
+// sets the location of a bank from its town, any location given by the caller is replaced
+func locate(b *model.Bank) {
+	b.TownName = geo.NormalizeTown(b.TownName)
+	b.Location = nil
+	if gazetteer == nil || b.TownName == "" {
+		return
+	}
+	if loc, ok := gazetteer.Lookup(b.CountryCode, b.TownName); ok {
+		b.Location = &loc
+	}
+}
+
+// NULL coordinates for a bank without a location
+func coordinates(l *model.Location) (lat, lon sql.NullFloat64) {
+	if l == nil {
+		return lat, lon
+	}
+	return sql.NullFloat64{Float64: l.Latitude, Valid: true}, sql.NullFloat64{Float64: l.Longitude, Valid: true}
+}
+
+func location(lat, lon sql.NullFloat64) *model.Location {
+	if !lat.Valid || !lon.Valid {
+		return nil
+	}
+	return &model.Location{Latitude: lat.Float64, Longitude: lon.Float64}
+}
+
+// banks within radiusKm of center, closest first, optionally only of one country.
+// The bounding box of the circle is matched with the banks_location index, the exact
+// distance (haversine, like geo.Distance) only for the rows inside it.
+func NearbyBanksContext(ctx context.Context, db *sql.DB, center model.Location, radiusKm float64, countryCode string, limit int) (banks []model.NearbyBank, err error) {
+	ctx, done := observe(ctx, "NearbyBanks", &err)
+	defer done()
+
+	box := geo.Bounds(center, radiusKm)
+	rows, err := db.QueryContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at, COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address, distance_km
+	FROM (
+		SELECT *, 2 * $10::float8 * ASIN(LEAST(1, SQRT(
+			POWER(SIN(RADIANS(latitude - $1::float8) / 2), 2) +
+			COS(RADIANS($1::float8)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2::float8) / 2), 2)
+		))) AS distance_km
+		FROM banks
+		WHERE latitude BETWEEN $3::float8 AND $4::float8
+			AND (longitude BETWEEN $5::float8 AND $6::float8
+				OR ($5::float8 > $6::float8 AND (longitude >= $5::float8 OR longitude <= $6::float8)))
+			AND ($7 = '' OR country_code = $7)
+	) candidates
+	WHERE distance_km <= $8::float8
+	ORDER BY distance_km, swift_code
+	LIMIT $9`,
+		center.Latitude, center.Longitude,
+		box.MinLat, box.MaxLat, box.MinLon, box.MaxLon,
+		strings.ToUpper(countryCode), radiusKm, limit, geo.EarthRadiusKm)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b model.NearbyBank
+		var lat, lon sql.NullFloat64
+		var postal []byte
+		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt,
+			&b.TownName, &lat, &lon, &b.TimeZone, &postal, &b.DistanceKm)
+		if err != nil {
+			return nil, err
+		}
+		b.Location = location(lat, lon)
+		b.PostalAddress = postalAddress(postal)
+		banks = append(banks, b)
+	}
+	return banks, rows.Err()
+}
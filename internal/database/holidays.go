@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// imports the holidays read by parse from path only if the holidays table is empty,
+// holidays is nil when nothing was imported. The file is only read when needed.
+func SeedHolidaysIfEmpty(ctx context.Context, db *sql.DB, path string, parse func(path string) ([]model.Holiday, error)) (holidays []model.Holiday, err error) {
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM holidays").Scan(&count); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		slog.Info("Holidays already imported, skipping seed", "file", path)
+		return nil, nil
+	}
+	if holidays, err = parse(path); err != nil {
+		return nil, err
+	}
+	return holidays, ImportHolidaysContext(ctx, db, holidays)
+}
+
+// upserts holidays in one transaction, a holiday on a stored date replaces its name
+func ImportHolidaysContext(ctx context.Context, db *sql.DB, holidays []model.Holiday) (err error) {
+	ctx, done := observe(ctx, "ImportHolidays", &err)
+	defer done()
+
+	start := time.Now()
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO holidays (country_code, date, name)
+		VALUES ($1, $2::date, $3)
+		ON CONFLICT (country_code, date) DO UPDATE SET name = EXCLUDED.name`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, h := range holidays {
+			if _, err := stmt.ExecContext(ctx, strings.ToUpper(h.CountryCode), h.Date, h.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Holiday import finished", "holidays", len(holidays), "duration", time.Since(start))
+	return nil
+}
+
+// holidays of a country between from and to (dates, both included), ordered by date
+func ListHolidaysContext(ctx context.Context, db *sql.DB, countryCode string, from, to time.Time) (holidays []model.Holiday, err error) {
+	ctx, done := observe(ctx, "ListHolidays", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT country_code, date, name
+	FROM holidays
+	WHERE country_code = $1 AND date BETWEEN $2::date AND $3::date
+	ORDER BY date`,
+		strings.ToUpper(countryCode), from.Format(model.DateLayout), to.Format(model.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h model.Holiday
+		var date time.Time
+		if err := rows.Scan(&h.CountryCode, &date, &h.Name); err != nil {
+			return nil, err
+		}
+		h.Date = date.Format(model.DateLayout)
+		holidays = append(holidays, h)
+	}
+	return holidays, rows.Err()
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// links national identifiers to the banks of their SWIFT codes, an identifier that
+// is already linked moves to the new bank. Identifiers whose SWIFT code is not in
+// the directory are counted as unmatched, rows that fail are logged and counted.
+func ImportIdentifiersContext(ctx context.Context, db *sql.DB, ids []model.BankIdentifier) (unmatched, failed int, err error) {
+	ctx, done := observe(ctx, "ImportIdentifiers", &err)
+	defer done()
+
+	start := time.Now()
+	logger := logging.FromContext(ctx)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return unmatched, failed, err
+		}
+		result, err := db.ExecContext(ctx, `
+		INSERT INTO bank_identifiers (scheme, identifier, bank_id)
+		SELECT $1, $2, id FROM banks WHERE swift_code = $3
+		ON CONFLICT (scheme, identifier) DO UPDATE SET bank_id = EXCLUDED.bank_id`,
+			id.Scheme, id.Identifier, id.SwiftCode)
+		var n int64
+		if err == nil {
+			n, err = result.RowsAffected()
+		}
+		switch {
+		case err != nil:
+			logger.Error("Error when importing an identifier", "scheme", id.Scheme, "identifier", id.Identifier, "error", err)
+			failed++
+		case n == 0:
+			logger.Info("SWIFT code of an identifier is not in the directory", "scheme", id.Scheme, "identifier", id.Identifier, "swift_code", id.SwiftCode)
+			unmatched++
+		}
+	}
+	logger.Info("Identifier import finished", "rows", len(ids), "unmatched", unmatched, "failed", failed, "duration", time.Since(start))
+	return unmatched, failed, nil
+}
+
+// the bank linked to a national identifier with the same fields as GetBankBySwiftCodeContext,
+// sql.ErrNoRows if it is not linked
+func GetBankByIdentifierContext(ctx context.Context, db *sql.DB, scheme, identifier string) (bank *model.Bank, err error) {
+	ctx, done := observe(ctx, "GetBankByIdentifier", &err)
+	defer done()
+
+	var b model.Bank
+	var lat, lon sql.NullFloat64
+	var postal []byte
+	err = db.QueryRowContext(ctx, `
+	SELECT bank_name, address, country_code, country_name, swift_code, is_headquarter, updated_at,
+		COALESCE(town_name, ''), latitude, longitude, COALESCE(time_zone, ''), postal_address, `+screeningStatus+`
+	FROM banks
+	WHERE id = (SELECT bank_id FROM bank_identifiers WHERE scheme = $1 AND identifier = $2)`, scheme, identifier).
+		Scan(&b.Name, &b.Address, &b.CountryCode, &b.CountryName, &b.SwiftCode, &b.IsHeadquarter, &b.UpdatedAt,
+			&b.TownName, &lat, &lon, &b.TimeZone, &postal, &b.ScreeningStatus)
+	if err != nil {
+		return nil, err
+	}
+	b.Location = location(lat, lon)
+	b.PostalAddress = postalAddress(postal)
+	return &b, nil
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// every bank of the directory ordered by SWIFT code, for checks that need all rows at once
+func AllBanksContext(ctx context.Context, db *sql.DB) (banks []model.Bank, err error) {
+	err = StreamBanks(ctx, db, "", func(b model.Bank) error {
+		banks = append(banks, b)
+		return nil
+	})
+	return banks, err
+}
+
+// stores corrected banks with an updated event each and returns those that were stored.
+// A bank changed since it was read (UpdatedAt) is skipped, a later run fixes it.
+func FixBanksContext(ctx context.Context, db *sql.DB, banks []model.Bank) (fixed []model.Bank, err error) {
+	ctx, done := observe(ctx, "FixBanks", &err)
+	defer done()
+
+	for _, b := range banks {
+		updated, err := UpdateBankContext(ctx, db, b, b.UpdatedAt)
+		if err != nil {
+			return fixed, err
+		}
+		if updated == 0 {
+			logging.FromContext(ctx).Info("Bank changed during the quality run, not fixed", "swift_code", b.SwiftCode)
+			continue
+		}
+		fixed = append(fixed, b)
+	}
+	return fixed, nil
+}
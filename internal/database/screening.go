@@ -0,0 +1,353 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/screening"
+)
+
+// screening status of the banks row of a query, see the model.Screening* constants.
+// A reviewed match outranks open hits, both outrank a stale screening.
+const screeningStatus = `CASE
+		WHEN EXISTS (SELECT 1 FROM screening_hits h WHERE h.bank_id = banks.id AND h.status = 'accepted') THEN 'match'
+		WHEN EXISTS (SELECT 1 FROM screening_hits h WHERE h.bank_id = banks.id AND h.status = 'open') THEN 'potential-match'
+		WHEN banks.screened_at IS NULL OR banks.screened_at < banks.updated_at THEN 'not-screened'
+		ELSE 'clear' END`
+
+// ScreeningResult counts the work of a screening run
+type ScreeningResult struct {
+	Banks   int // screened banks
+	Hits    int // hits of the screened banks
+	NewHits int // hits that were not reported before
+}
+
+// ScreeningHitFilter narrows down a hit list, empty fields match everything
+type ScreeningHitFilter struct {
+	Status      string
+	CountryCode string
+	SwiftCode   string
+}
+
+// replaces the entries of a sanctions list in one transaction and returns the number of
+// entries that are no longer listed
+func ImportSanctionListContext(ctx context.Context, db *sql.DB, list string, entries []model.SanctionEntry) (removed int64, err error) {
+	ctx, done := observe(ctx, "ImportSanctionList", &err)
+	defer done()
+
+	start := time.Now()
+	ids := make([]string, 0, len(entries))
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO sanction_entries (list, entry_id, name, aliases, countries, program)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (list, entry_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			aliases = EXCLUDED.aliases,
+			countries = EXCLUDED.countries,
+			program = EXCLUDED.program`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, e := range entries {
+			aliases, countries := e.Aliases, e.Countries
+			if aliases == nil {
+				aliases = []string{}
+			}
+			if countries == nil {
+				countries = []string{}
+			}
+			if _, err := stmt.ExecContext(ctx, list, e.EntryID, e.Name, pq.Array(aliases), pq.Array(countries), e.Program); err != nil {
+				return err
+			}
+			ids = append(ids, e.EntryID)
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM sanction_entries WHERE list = $1 AND NOT (entry_id = ANY($2))", list, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		removed, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	logging.FromContext(ctx).Info("Sanctions list import finished", "list", list, "entries", len(entries), "removed", removed, "duration", time.Since(start))
+	return removed, nil
+}
+
+// entries of all imported sanctions lists
+func ListSanctionEntriesContext(ctx context.Context, db *sql.DB) (entries []model.SanctionEntry, err error) {
+	ctx, done := observe(ctx, "ListSanctionEntries", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, "SELECT list, entry_id, name, aliases, countries, program FROM sanction_entries ORDER BY list, entry_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.SanctionEntry
+		if err := rows.Scan(&e.List, &e.EntryID, &e.Name, pq.Array(&e.Aliases), pq.Array(&e.Countries), &e.Program); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+type screenedBank struct {
+	id                int
+	name, countryCode string
+}
+
+// screens banks against the matcher and records their hits. Without all only banks
+// that were never screened or changed since their last screening are screened.
+// Open hits that no longer match are removed, reviewed ones are kept; a reviewed hit
+// is opened again when the bank or the matched name changed.
+func ScreenBanksContext(ctx context.Context, db *sql.DB, matcher *screening.Matcher, all bool) (result ScreeningResult, err error) {
+	ctx, done := observe(ctx, "ScreenBanks", &err)
+	defer done()
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, `
+	SELECT id, COALESCE(bank_name, ''), COALESCE(country_code, '')
+	FROM banks
+	WHERE $1::boolean OR screened_at IS NULL OR screened_at < updated_at
+	ORDER BY id`, all)
+	if err != nil {
+		return result, err
+	}
+	var banks []screenedBank
+	for rows.Next() {
+		var b screenedBank
+		if err := rows.Scan(&b.id, &b.name, &b.countryCode); err != nil {
+			rows.Close()
+			return result, err
+		}
+		banks = append(banks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, b := range banks {
+		hits, newHits, err := screenBank(ctx, db, b, matcher.Match(b.name, b.countryCode))
+		if err != nil {
+			return result, err
+		}
+		result.Banks++
+		result.Hits += hits
+		result.NewHits += newHits
+	}
+	logging.FromContext(ctx).Info("Screening finished", "banks", result.Banks, "hits", result.Hits, "new_hits", result.NewHits, "duration", time.Since(start))
+	return result, nil
+}
+
+// records the matches of one bank and marks it screened
+func screenBank(ctx context.Context, db *sql.DB, b screenedBank, matches []screening.Match) (hits, newHits int, err error) {
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		before, err := bankScreeningStatus(ctx, tx, b.id)
+		if err != nil {
+			return err
+		}
+		// list and entry ID joined by a unit separator
+		keys := make([]string, 0, len(matches))
+		for _, m := range matches {
+			var inserted bool
+			err := tx.QueryRowContext(ctx, `
+			INSERT INTO screening_hits (bank_id, list, entry_id, entry_name, matched_name, program, bank_name, score)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (bank_id, list, entry_id) DO UPDATE SET
+				entry_name = EXCLUDED.entry_name,
+				matched_name = EXCLUDED.matched_name,
+				program = EXCLUDED.program,
+				bank_name = EXCLUDED.bank_name,
+				score = EXCLUDED.score,
+				status = CASE
+					WHEN screening_hits.bank_name <> EXCLUDED.bank_name OR screening_hits.matched_name <> EXCLUDED.matched_name THEN 'open'
+					ELSE screening_hits.status END,
+				updated_at = CASE
+					WHEN screening_hits.bank_name <> EXCLUDED.bank_name OR screening_hits.matched_name <> EXCLUDED.matched_name
+						OR screening_hits.score <> EXCLUDED.score THEN now()
+					ELSE screening_hits.updated_at END
+			RETURNING xmax = 0`,
+				b.id, m.Entry.List, m.Entry.EntryID, m.Entry.Name, m.Name, m.Entry.Program, b.name, m.Score,
+			).Scan(&inserted)
+			if err != nil {
+				return err
+			}
+			if inserted {
+				newHits++
+			}
+			keys = append(keys, m.Entry.List+"\x1f"+m.Entry.EntryID)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		DELETE FROM screening_hits
+		WHERE bank_id = $1 AND status = 'open' AND NOT (list || chr(31) || entry_id = ANY($2))`,
+			b.id, pq.Array(keys))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE banks SET screened_at = now() WHERE id = $1", b.id)
+		if err != nil {
+			return err
+		}
+		return touchOnStatusChange(ctx, tx, b.id, before)
+	})
+	return len(matches), newHits, err
+}
+
+// reads the screening status of a bank as lookups report it
+func bankScreeningStatus(ctx context.Context, tx *sql.Tx, bankID int) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT "+screeningStatus+" FROM banks WHERE id = $1", bankID).Scan(&status)
+	return status, err
+}
+
+// bumps updated_at of a bank whose screening status differs from before, so clients
+// revalidating with If-Modified-Since see the new status; a bank that was screened
+// stays screened
+func touchOnStatusChange(ctx context.Context, tx *sql.Tx, bankID int, before string) error {
+	after, err := bankScreeningStatus(ctx, tx, bankID)
+	if err != nil || after == before {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE banks SET
+		updated_at = now(),
+		screened_at = CASE WHEN screened_at >= updated_at THEN now() ELSE screened_at END
+	WHERE id = $1`, bankID)
+	return err
+}
+
+const screeningHitColumns = `
+	SELECT h.id, b.swift_code, b.country_code, h.bank_name, h.list, h.entry_id, h.entry_name,
+		h.matched_name, h.program, h.score, h.status, h.created_at, h.updated_at
+	FROM screening_hits h
+	JOIN banks b ON b.id = h.bank_id`
+
+func scanScreeningHit(row interface{ Scan(...any) error }) (model.ScreeningHit, error) {
+	var h model.ScreeningHit
+	err := row.Scan(&h.ID, &h.SwiftCode, &h.CountryCode, &h.BankName, &h.List, &h.EntryID, &h.EntryName,
+		&h.MatchedName, &h.Program, &h.Score, &h.Status, &h.CreatedAt, &h.UpdatedAt)
+	return h, err
+}
+
+// one page of hits ordered by ID, starting after the given ID (keyset pagination)
+func ListScreeningHitsContext(ctx context.Context, db *sql.DB, filter ScreeningHitFilter, after int64, limit int) (hits []model.ScreeningHit, err error) {
+	ctx, done := observe(ctx, "ListScreeningHits", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, screeningHitColumns+`
+	WHERE ($1 = '' OR h.status = $1)
+		AND ($2 = '' OR b.country_code = $2)
+		AND ($3 = '' OR b.swift_code = $3)
+		AND h.id > $4
+	ORDER BY h.id
+	LIMIT $5`,
+		filter.Status, strings.ToUpper(filter.CountryCode), strings.ToUpper(filter.SwiftCode), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := scanScreeningHit(rows)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// a hit with its decisions, oldest first. sql.ErrNoRows when there is no such hit.
+func GetScreeningHitContext(ctx context.Context, db *sql.DB, id int64) (hit *model.ScreeningHit, err error) {
+	ctx, done := observe(ctx, "GetScreeningHit", &err)
+	defer done()
+
+	h, err := scanScreeningHit(db.QueryRowContext(ctx, screeningHitColumns+" WHERE h.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	if h.Decisions, err = screeningDecisions(ctx, db, id); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func screeningDecisions(ctx context.Context, db *sql.DB, hitID int64) ([]model.ScreeningDecision, error) {
+	rows, err := db.QueryContext(ctx, `
+	SELECT decision, reviewer, comment, decided_at
+	FROM screening_decisions
+	WHERE hit_id = $1
+	ORDER BY id`, hitID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var decisions []model.ScreeningDecision
+	for rows.Next() {
+		var d model.ScreeningDecision
+		if err := rows.Scan(&d.Decision, &d.Reviewer, &d.Comment, &d.DecidedAt); err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
+// accepts or dismisses a hit and writes the decision to the audit log in the same
+// transaction, sql.ErrNoRows when there is no such hit
+func DecideScreeningHitContext(ctx context.Context, db *sql.DB, id int64, d model.ScreeningDecision) (hit *model.ScreeningHit, err error) {
+	ctx, done := observe(ctx, "DecideScreeningHit", &err)
+	defer done()
+
+	status := model.HitDismissed
+	if d.Decision == model.DecisionAccept {
+		status = model.HitAccepted
+	}
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		h, err := scanScreeningHit(tx.QueryRowContext(ctx, screeningHitColumns+" WHERE h.id = $1 FOR UPDATE OF h", id))
+		if err != nil {
+			return err
+		}
+		var bankID int
+		if err := tx.QueryRowContext(ctx, "SELECT bank_id FROM screening_hits WHERE id = $1", id).Scan(&bankID); err != nil {
+			return err
+		}
+		before, err := bankScreeningStatus(ctx, tx, bankID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE screening_hits SET status = $2, updated_at = now() WHERE id = $1", id, status); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO screening_decisions (hit_id, swift_code, list, entry_id, bank_name, matched_name, score, decision, reviewer, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			id, h.SwiftCode, h.List, h.EntryID, h.BankName, h.MatchedName, h.Score, d.Decision, d.Reviewer, d.Comment)
+		if err != nil {
+			return err
+		}
+		return touchOnStatusChange(ctx, tx, bankID, before)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetScreeningHitContext(ctx, db, id)
+}
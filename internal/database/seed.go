@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// imports the banks read by parse from path with ImportBanks only if the banks table is
+// empty or an earlier seed was interrupted, banks is nil when nothing was imported. The
+// seed is marked complete only after the whole file was imported, so a seed stopped
+// halfway is resumed by the next call.
+func SeedIfEmpty(ctx context.Context, db *sql.DB, path string, parse func(path string) ([]model.Bank, error)) (banks []model.Bank, err error) {
+	var interrupted bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM seeds WHERE completed_at IS NULL)").Scan(&interrupted)
+	if err != nil {
+		return nil, err
+	}
+
+	if interrupted {
+		slog.Info("Resuming an interrupted seed", "file", path)
+	} else {
+		empty, err := IsDatabaseEmptyContext(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			slog.Info("Database already has data, skipping seed", "file", path)
+			return nil, nil
+		}
+
+		slog.Info("Add new data from .csv file as database is empty", "file", path)
+		if _, err := db.ExecContext(ctx, "INSERT INTO seeds (file) VALUES ($1)", path); err != nil {
+			return nil, err
+		}
+	}
+
+	if banks, err = parse(path); err != nil {
+		return nil, err
+	}
+	if _, err := ImportBanks(ctx, db, banks); err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE seeds SET completed_at = now() WHERE completed_at IS NULL"); err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// stores a subscription and sets its ID and creation time
+func CreateWebhookContext(ctx context.Context, db *sql.DB, w *model.Webhook) (err error) {
+	ctx, done := observe(ctx, "CreateWebhook", &err)
+	defer done()
+
+	return db.QueryRowContext(ctx, `
+	INSERT INTO webhook_subscriptions (url, secret, event_types)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at`, w.URL, w.Secret, pq.Array(w.EventTypes)).Scan(&w.ID, &w.CreatedAt)
+}
+
+// all subscriptions without their secrets
+func ListWebhooksContext(ctx context.Context, db *sql.DB) (webhooks []model.Webhook, err error) {
+	ctx, done := observe(ctx, "ListWebhooks", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT id, url, event_types, created_at
+	FROM webhook_subscriptions
+	ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w model.Webhook
+		if err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.EventTypes), &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// removes a subscription with its pending deliveries, sql.ErrNoRows if it does not exist
+func DeleteWebhookContext(ctx context.Context, db *sql.DB, id int64) (err error) {
+	ctx, done := observe(ctx, "DeleteWebhook", &err)
+	defer done()
+
+	result, err := db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
+
+// the latest deliveries of a subscription with every attempt, sql.ErrNoRows if the subscription does not exist
+func ListWebhookDeliveriesContext(ctx context.Context, db *sql.DB, id int64, limit int) (deliveries []model.WebhookDelivery, err error) {
+	ctx, done := observe(ctx, "ListWebhookDeliveries", &err)
+	defer done()
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT d.id, d.event_id, e.type, d.status, d.next_attempt_at, d.created_at,
+		a.attempted_at, a.status_code, a.error, a.duration_ms
+	FROM (
+		SELECT * FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2
+	) d
+	JOIN events e ON e.id = d.event_id
+	LEFT JOIN webhook_attempts a ON a.delivery_id = d.id
+	ORDER BY d.id DESC, a.id`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries = []model.WebhookDelivery{}
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var next time.Time
+		var attemptedAt sql.NullTime
+		var statusCode, duration sql.NullInt64
+		var attemptErr sql.NullString
+		err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &d.Status, &next, &d.CreatedAt,
+			&attemptedAt, &statusCode, &attemptErr, &duration)
+		if err != nil {
+			return nil, err
+		}
+
+		// rows of the same delivery follow each other
+		if n := len(deliveries); n == 0 || deliveries[n-1].ID != d.ID {
+			d.Attempts = []model.WebhookAttempt{}
+			if d.Status == DeliveryPending {
+				d.NextAttemptAt = &next
+			}
+			deliveries = append(deliveries, d)
+		}
+		if attemptedAt.Valid {
+			last := &deliveries[len(deliveries)-1]
+			last.Attempts = append(last.Attempts, model.WebhookAttempt{
+				AttemptedAt: attemptedAt.Time,
+				StatusCode:  int(statusCode.Int64),
+				Error:       attemptErr.String,
+				DurationMs:  duration.Int64,
+			})
+		}
+	}
+	return deliveries, rows.Err()
+}
+
+// PendingDelivery is a delivery claimed by the dispatcher, with everything needed to send it
+type PendingDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	Attempts       int // attempts made before this one
+	URL            string
+	Secret         string
+	Event          model.Event
+}
+
+// claims up to limit due deliveries. Claimed deliveries are not due again for the lease,
+// so several instances can dispatch without sending the same delivery twice.
+func ClaimWebhookDeliveriesContext(ctx context.Context, db *sql.DB, limit int, lease time.Duration) (pending []PendingDelivery, err error) {
+	ctx, done := observe(ctx, "ClaimWebhookDeliveries", &err)
+	defer done()
+
+	rows, err := db.QueryContext(ctx, `
+	WITH due AS (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d
+	SET next_attempt_at = now() + make_interval(secs => $2)
+	FROM due, webhook_subscriptions s, events e
+	WHERE d.id = due.id AND s.id = d.subscription_id AND e.id = d.event_id
+	RETURNING d.id, d.subscription_id, d.attempts, s.url, s.secret,
+		e.id, e.type, e.swift_code, e.country_code, e.payload, e.created_at`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p PendingDelivery
+		var swiftCode, countryCode sql.NullString
+		err := rows.Scan(&p.ID, &p.SubscriptionID, &p.Attempts, &p.URL, &p.Secret,
+			&p.Event.ID, &p.Event.Type, &swiftCode, &countryCode, &p.Event.Data, &p.Event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		p.Event.SwiftCode = swiftCode.String
+		p.Event.CountryCode = countryCode.String
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// logs an attempt and moves the delivery to its new status,
+// nextAttempt is only used while the delivery stays pending
+func RecordWebhookAttemptContext(ctx context.Context, db *sql.DB, deliveryID int64, attempt model.WebhookAttempt, status string, nextAttempt time.Time) (err error) {
+	ctx, done := observe(ctx, "RecordWebhookAttempt", &err)
+	defer done()
+
+	return withTx(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5)`,
+			deliveryID, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.DurationMs)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, status = $2, next_attempt_at = $3
+		WHERE id = $1`, deliveryID, status, nextAttempt)
+		return err
+	})
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// same column layout as data/2025_SWIFT_CODES.csv so exports can be imported again
+var CSVHeader = []string{
+	"COUNTRY ISO2 CODE",
+	"SWIFT CODE",
+	"CODE TYPE",
+	"NAME",
+	"ADDRESS",
+	"TOWN NAME",
+	"COUNTRY NAME",
+	"TIME ZONE",
+}
+
+// Writer encodes banks one by one, Close finishes the document
+type Writer interface {
+	Write(b model.Bank) error
+	Close() error
+}
+
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	New         func(w io.Writer) Writer
+}
+
+var Formats = map[string]Format{
+	"csv":   {Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: "csv", New: NewCSVWriter},
+	"jsonl": {Name: "jsonl", ContentType: "application/x-ndjson", Extension: "jsonl", New: NewJSONLinesWriter},
+	"json":  {Name: "json", ContentType: "application/json; charset=utf-8", Extension: "json", New: NewJSONArrayWriter},
+}
+
+func LookupFormat(name string) (Format, error) {
+	f, ok := Formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported export format %q", name)
+	}
+	return f, nil
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) Write(b model.Bank) error {
+	if !cw.wroteHeader {
+		if err := cw.w.Write(CSVHeader); err != nil {
+			return err
+		}
+		cw.wroteHeader = true
+	}
+	err := cw.w.Write([]string{
+		b.CountryCode,
+		b.SwiftCode,
+		"BIC11",
+		b.Name,
+		b.Address,
+		b.TownName,
+		b.CountryName,
+		b.TimeZone,
+	})
+	if err != nil {
+		return err
+	}
+	// flush regularly so rows reach the client while the cursor is still open
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	if !cw.wroteHeader {
+		if err := cw.w.Write(CSVHeader); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+func NewJSONLinesWriter(w io.Writer) Writer {
+	return &jsonLinesWriter{enc: json.NewEncoder(w)}
+}
+
+func (jw *jsonLinesWriter) Write(b model.Bank) error {
+	return jw.enc.Encode(b)
+}
+
+func (jw *jsonLinesWriter) Close() error {
+	return nil
+}
+
+type jsonArrayWriter struct {
+	w     io.Writer
+	count int
+}
+
+func NewJSONArrayWriter(w io.Writer) Writer {
+	return &jsonArrayWriter{w: w}
+}
+
+func (jw *jsonArrayWriter) Write(b model.Bank) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n"
+	if jw.count == 0 {
+		prefix = "[\n"
+	}
+	jw.count++
+
+	if _, err := io.WriteString(jw.w, prefix); err != nil {
+		return err
+	}
+	_, err = jw.w.Write(data)
+	return err
+}
+
+func (jw *jsonArrayWriter) Close() error {
+	closing := "\n]\n"
+	if jw.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(jw.w, closing)
+	return err
+}
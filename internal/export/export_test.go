@@ -0,0 +1,105 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/export"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+)
+
+var testBanks = []model.Bank{
+	{
+		Address:       "HYRJA 3 RR. DRITAN HOXHA ND. 11 TIRANA, TIRANA, 1023",
+		Name:          "UNITED BANK OF ALBANIA SH.A",
+		CountryCode:   "AL",
+		CountryName:   "ALBANIA",
+		IsHeadquarter: true,
+		SwiftCode:     "AAISALTRXXX",
+	},
+	{
+		Address:       "Branch Address",
+		Name:          "Bank Test Name Branch",
+		CountryCode:   "PL",
+		CountryName:   "POLAND",
+		IsHeadquarter: false,
+		SwiftCode:     "TESTPLPW123",
+	},
+}
+
+func TestCSVWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewCSVWriter(&buf)
+	for _, b := range testBanks {
+		assert.NoError(t, w.Write(b))
+	}
+	assert.NoError(t, w.Close())
+
+	assert.True(t, strings.HasPrefix(buf.String(), "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n"))
+
+	// the export must be readable by the importer
+	path := filepath.Join(t.TempDir(), "export.csv")
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	banks, err := parser.ParseSwiftCSV(path)
+	assert.NoError(t, err)
+	assert.Equal(t, testBanks, banks)
+}
+
+func TestCSVWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewCSVWriter(&buf)
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, strings.Join(export.CSVHeader, ",")+"\n", buf.String(), "Empty export should still contain the header")
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewJSONLinesWriter(&buf)
+	for _, b := range testBanks {
+		assert.NoError(t, w.Write(b))
+	}
+	assert.NoError(t, w.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var b model.Bank
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &b))
+	assert.Equal(t, "TESTPLPW123", b.SwiftCode)
+}
+
+func TestJSONArrayWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewJSONArrayWriter(&buf)
+	for _, b := range testBanks {
+		assert.NoError(t, w.Write(b))
+	}
+	assert.NoError(t, w.Close())
+
+	var banks []model.Bank
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &banks))
+	assert.Equal(t, testBanks, banks)
+
+	// empty array
+	buf.Reset()
+	w = export.NewJSONArrayWriter(&buf)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestLookupFormat(t *testing.T) {
+	f, err := export.LookupFormat("JSONL")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", f.ContentType)
+
+	_, err = export.LookupFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package geo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// mean earth radius used for all distances
+const EarthRadiusKm = 6371.0088
+
+// Town is a gazetteer entry
+type Town struct {
+	CountryCode string
+	Name        string
+	Location    model.Location
+}
+
+// Gazetteer maps town names to coordinates, see data/gazetteer.csv
+type Gazetteer struct {
+	towns map[string][]Town // normalized name -> towns of that name in different countries
+	count int
+}
+
+// LoadGazetteer reads a CSV with the columns COUNTRY ISO2 CODE, TOWN NAME, LATITUDE
+// and LONGITUDE, lines starting with # are comments
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	g := &Gazetteer{towns: map[string][]Town{}}
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		lat, err1 := strconv.ParseFloat(rec[2], 64)
+		lon, err2 := strconv.ParseFloat(rec[3], 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("%s: line %d: invalid coordinates %s, %s", path, line, rec[2], rec[3])
+		}
+		g.Add(Town{CountryCode: rec[0], Name: rec[1], Location: model.Location{Latitude: lat, Longitude: lon}})
+	}
+}
+
+// Add adds a town or replaces the coordinates of a town with the same name and country
+func (g *Gazetteer) Add(t Town) {
+	t.CountryCode = strings.ToUpper(t.CountryCode)
+	key := NormalizeTown(t.Name)
+	towns := g.towns[key]
+	for i := range towns {
+		if towns[i].CountryCode == t.CountryCode {
+			towns[i] = t
+			return
+		}
+	}
+	g.towns[key] = append(towns, t)
+	g.count++
+}
+
+// Lookup returns the coordinates of a town in a country
+func (g *Gazetteer) Lookup(countryCode, town string) (model.Location, bool) {
+	countryCode = strings.ToUpper(countryCode)
+	for _, t := range g.towns[NormalizeTown(town)] {
+		if t.CountryCode == countryCode {
+			return t.Location, true
+		}
+	}
+	return model.Location{}, false
+}
+
+// Find returns every town with the name, in any country
+func (g *Gazetteer) Find(town string) []Town {
+	return g.towns[NormalizeTown(town)]
+}
+
+// Len returns the number of towns
+func (g *Gazetteer) Len() int {
+	return g.count
+}
+
+// NormalizeTown upper cases a town name and collapses its spaces
+func NormalizeTown(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
+
+// Distance returns the great-circle distance in kilometres (haversine)
+func Distance(a, b model.Location) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a latitude/longitude rectangle, MinLon > MaxLon when it crosses the antimeridian
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Bounds returns a box containing every point within radiusKm of center,
+// used to narrow a distance search down with an index
+func Bounds(center model.Location, radiusKm float64) Box {
+	dLat := degrees(radiusKm / EarthRadiusKm)
+	box := Box{
+		MinLat: math.Max(center.Latitude-dLat, -90),
+		MaxLat: math.Min(center.Latitude+dLat, 90),
+		MinLon: -180,
+		MaxLon: 180,
+	}
+	// a pole inside the circle covers every longitude
+	if box.MinLat == -90 || box.MaxLat == 90 {
+		return box
+	}
+
+	// widest longitude offset of the circle, reached north or south of the centre
+	s := math.Sin(radiusKm/EarthRadiusKm) / math.Cos(radians(center.Latitude))
+	if s >= 1 {
+		return box
+	}
+	dLon := degrees(math.Asin(s))
+	box.MinLon = wrapLongitude(center.Longitude - dLon)
+	box.MaxLon = wrapLongitude(center.Longitude + dLon)
+	return box
+}
+
+func wrapLongitude(lon float64) float64 {
+	switch {
+	case lon < -180:
+		return lon + 360
+	case lon > 180:
+		return lon - 360
+	}
+	return lon
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
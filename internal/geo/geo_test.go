@@ -0,0 +1,105 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/geo"
+	"github.com/white67/swift_api/internal/model"
+)
+
+func TestLoadGazetteer(t *testing.T) {
+	g, err := geo.LoadGazetteer("../../data/gazetteer.csv")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 170, g.Len())
+
+	loc, ok := g.Lookup("pl", " warszawa ")
+	assert.True(t, ok)
+	assert.InDelta(t, 52.23, loc.Latitude, 0.01)
+	assert.InDelta(t, 21.01, loc.Longitude, 0.01)
+
+	_, ok = g.Lookup("DE", "WARSZAWA")
+	assert.False(t, ok, "Towns are looked up per country")
+
+	loc, ok = g.Lookup("MT", "ST.  JULIAN'S")
+	assert.True(t, ok, "Spaces should be collapsed")
+	assert.InDelta(t, 35.92, loc.Latitude, 0.01)
+}
+
+func TestGazetteer_Find(t *testing.T) {
+	g, err := geo.LoadGazetteer("../../data/gazetteer.csv")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	g.Add(geo.Town{CountryCode: "ma", Name: "Rabat", Location: model.Location{Latitude: 34.0209, Longitude: -6.8416}})
+
+	towns := g.Find("rabat")
+	if assert.Len(t, towns, 2) {
+		assert.ElementsMatch(t, []string{"MT", "MA"}, []string{towns[0].CountryCode, towns[1].CountryCode})
+	}
+	assert.Empty(t, g.Find("ATLANTIS"))
+}
+
+func TestDistance(t *testing.T) {
+	warsaw := model.Location{Latitude: 52.2297, Longitude: 21.0122}
+	krakow := model.Location{Latitude: 50.0647, Longitude: 19.9450}
+
+	assert.InDelta(t, 252, geo.Distance(warsaw, krakow), 1)
+	assert.InDelta(t, geo.Distance(warsaw, krakow), geo.Distance(krakow, warsaw), 1e-9)
+	assert.Zero(t, geo.Distance(warsaw, warsaw))
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		center model.Location
+		radius float64
+	}{
+		{"warsaw", model.Location{Latitude: 52.2297, Longitude: 21.0122}, 50},
+		{"south", model.Location{Latitude: -53.1638, Longitude: -70.9171}, 200},
+		{"antimeridian", model.Location{Latitude: -17.7134, Longitude: 178.065}, 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := geo.Bounds(tt.center, tt.radius)
+			// points on the circle in every direction are inside the box
+			for bearing := 0; bearing < 360; bearing += 15 {
+				p := destination(tt.center, float64(bearing), tt.radius*0.999)
+				assert.InDelta(t, tt.radius, geo.Distance(tt.center, p), tt.radius*0.01)
+				assert.True(t, p.Latitude >= box.MinLat && p.Latitude <= box.MaxLat, "latitude at bearing %d", bearing)
+				inLon := p.Longitude >= box.MinLon && p.Longitude <= box.MaxLon
+				if box.MinLon > box.MaxLon {
+					inLon = p.Longitude >= box.MinLon || p.Longitude <= box.MaxLon
+				}
+				assert.True(t, inLon, "longitude at bearing %d", bearing)
+			}
+		})
+	}
+
+	box := geo.Bounds(model.Location{Latitude: -17.7134, Longitude: 178.065}, 300)
+	assert.Greater(t, box.MinLon, box.MaxLon, "Box should wrap around the antimeridian")
+
+	box = geo.Bounds(model.Location{Latitude: 89.9, Longitude: 0}, 50)
+	assert.Equal(t, geo.Box{MinLat: box.MinLat, MaxLat: 90, MinLon: -180, MaxLon: 180}, box, "A pole in the circle covers every longitude")
+}
+
+// point at a distance and bearing from start, on a sphere
+func destination(start model.Location, bearingDeg, km float64) model.Location {
+	d := km / geo.EarthRadiusKm
+	lat1, lon1 := start.Latitude*math.Pi/180, start.Longitude*math.Pi/180
+	bearing := bearingDeg * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(bearing))
+	lon2 := lon1 + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+
+	lon := lon2 * 180 / math.Pi
+	if lon > 180 {
+		lon -= 360
+	} else if lon < -180 {
+		lon += 360
+	}
+	return model.Location{Latitude: lat2 * 180 / math.Pi, Longitude: lon}
+}
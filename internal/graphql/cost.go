@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// maxCost is the most banks a single query may load, counted over every level of
+// the query. MaxDepth alone allows wide queries that load far more.
+const maxCost = 5000
+
+var errTooExpensive = fmt.Errorf("query loads more than %d banks, ask for fewer fields or smaller pages", maxCost)
+
+// the banks loaded by one query, they are charged to the lookup quota afterwards
+type cost struct {
+	mu    sync.Mutex
+	banks int
+}
+
+type costKey struct{}
+
+func withCost(ctx context.Context) (context.Context, *cost) {
+	c := &cost{}
+	return context.WithValue(ctx, costKey{}, c), c
+}
+
+func (c *cost) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.banks
+}
+
+// adds n banks, fails once the limit is passed. Queries without a cost (outside of
+// Handler) are not limited.
+func spend(ctx context.Context, n int) error {
+	c, ok := ctx.Value(costKey{}).(*cost)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.banks += n
+	if c.banks > maxCost {
+		return errTooExpensive
+	}
+	return nil
+}
+
+// costStore counts the banks loaded from the store, no more is loaded once the
+// limit is passed
+type costStore struct {
+	Store
+}
+
+func (s costStore) load(ctx context.Context, load func() ([]model.Bank, error)) ([]model.Bank, error) {
+	if err := spend(ctx, 0); err != nil {
+		return nil, err
+	}
+	banks, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := spend(ctx, len(banks)); err != nil {
+		return nil, err
+	}
+	return banks, nil
+}
+
+func (s costStore) Banks(ctx context.Context, swiftCodes []string) ([]model.Bank, error) {
+	return s.load(ctx, func() ([]model.Bank, error) { return s.Store.Banks(ctx, swiftCodes) })
+}
+
+func (s costStore) Branches(ctx context.Context, hqSwiftCodes []string) ([]model.Bank, error) {
+	return s.load(ctx, func() ([]model.Bank, error) { return s.Store.Branches(ctx, hqSwiftCodes) })
+}
+
+func (s costStore) CountryBanks(ctx context.Context, iso2, after string, limit int) ([]model.Bank, error) {
+	return s.load(ctx, func() ([]model.Bank, error) { return s.Store.CountryBanks(ctx, iso2, after, limit) })
+}
+
+func isTooExpensive(err error) bool {
+	return errors.Is(err, errTooExpensive)
+}
@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/ratelimit"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// nested queries deeper than this are rejected before any resolver runs
+const maxDepth = 8
+
+// Store is the data access used by the resolvers. Every method is called once per
+// batch of banks, never once per bank.
+type Store interface {
+	// banks with the given codes, missing codes are left out
+	Banks(ctx context.Context, swiftCodes []string) ([]model.Bank, error)
+	// branches of all given headquarters
+	Branches(ctx context.Context, hqSwiftCodes []string) ([]model.Bank, error)
+	// country name and number of banks, sql.ErrNoRows if the country has none
+	Country(ctx context.Context, iso2 string) (name string, total int, err error)
+	// up to limit banks of a country ordered by SWIFT code, starting after the given code
+	CountryBanks(ctx context.Context, iso2, after string, limit int) ([]model.Bank, error)
+}
+
+// DBStore reads from the shared database connection
+func DBStore() Store {
+	return dbStore{}
+}
+
+type dbStore struct{}
+
+func (dbStore) Banks(ctx context.Context, swiftCodes []string) ([]model.Bank, error) {
+	return database.GetBanksBySwiftCodesContext(ctx, config.GetDB(), swiftCodes)
+}
+
+func (dbStore) Branches(ctx context.Context, hqSwiftCodes []string) ([]model.Bank, error) {
+	return database.GetBranchesForHeadquartersContext(ctx, config.GetDB(), hqSwiftCodes)
+}
+
+func (dbStore) Country(ctx context.Context, iso2 string) (string, int, error) {
+	return database.GetCountrySummaryContext(ctx, config.GetDB(), iso2)
+}
+
+func (dbStore) CountryBanks(ctx context.Context, iso2, after string, limit int) ([]model.Bank, error) {
+	return database.GetBanksByCountryPageContext(ctx, config.GetDB(), iso2, after, limit)
+}
+
+// NewSchema parses the schema and binds it to the resolvers
+func NewSchema(store Store) (*gql.Schema, error) {
+	return gql.ParseSchema(schemaSDL, &queryResolver{store: costStore{store}}, gql.MaxDepth(maxDepth))
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Handler serves POST /graphql. Like other GraphQL servers it answers 200 with an
+// errors list for query errors, only malformed requests get a 400. Every bank a query
+// loads counts as one lookup against the quota of ratelimit.Metered, a query that
+// does not fit into the remaining quota gets a 429 without data.
+func Handler(store Store) (gin.HandlerFunc, error) {
+	schema, err := NewSchema(store)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+			return
+		}
+		if req.Query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "query must not be empty"})
+			return
+		}
+
+		ctx, cost := withCost(c.Request.Context())
+		resp := schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+		if !ratelimit.Charge(c, cost.total()) {
+			return
+		}
+		resp.Extensions = map[string]any{"cost": map[string]int{"banks": cost.total(), "limit": maxCost}}
+		c.JSON(http.StatusOK, resp)
+	}, nil
+}
+
+// database errors are logged by the resolvers and hidden from the caller
+var errDatabase = errors.New("database error")
+
+func isNotFound(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,328 @@
+package graphql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/graphql"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/ratelimit"
+)
+
+// in-memory store that counts how often each method is called
+type fakeStore struct {
+	banks []model.Bank // sorted by SWIFT code
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (s *fakeStore) count(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[name]++
+}
+
+func (s *fakeStore) Banks(_ context.Context, codes []string) ([]model.Bank, error) {
+	s.count("Banks")
+	var out []model.Bank
+	for _, b := range s.banks {
+		if slices.Contains(codes, b.SwiftCode) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) Branches(_ context.Context, hqs []string) ([]model.Bank, error) {
+	s.count("Branches")
+	var out []model.Bank
+	for _, b := range s.banks {
+		if !b.IsHeadquarter && slices.Contains(hqs, b.SwiftCode[:8]+"XXX") {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) Country(_ context.Context, iso2 string) (string, int, error) {
+	s.count("Country")
+	var name string
+	var total int
+	for _, b := range s.banks {
+		if b.CountryCode == iso2 {
+			name = b.CountryName
+			total++
+		}
+	}
+	if total == 0 {
+		return "", 0, sql.ErrNoRows
+	}
+	return name, total, nil
+}
+
+func (s *fakeStore) CountryBanks(_ context.Context, iso2, after string, limit int) ([]model.Bank, error) {
+	s.count("CountryBanks")
+	var out []model.Bank
+	for _, b := range s.banks {
+		if b.CountryCode == iso2 && b.SwiftCode > after && len(out) < limit {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func newFakeStore() *fakeStore {
+	bank := func(code string) model.Bank {
+		return model.Bank{
+			SwiftCode:     code,
+			Name:          "Bank " + code[:4],
+			Address:       "Street 1",
+			CountryCode:   "PL",
+			CountryName:   "POLAND",
+			IsHeadquarter: strings.HasSuffix(code, "XXX"),
+		}
+	}
+	return &fakeStore{
+		banks: []model.Bank{
+			bank("AAAAPLPW001"), bank("AAAAPLPW002"), bank("AAAAPLPWXXX"),
+			bank("BBBBPLPW001"), bank("BBBBPLPWXXX"),
+			bank("CCCCPLPWXXX"),
+		},
+		calls: map[string]int{},
+	}
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, store graphql.Store, q string) response {
+	h, err := graphql.Handler(store)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	router := gin.New()
+	router.POST("/graphql", h)
+
+	body, _ := json.Marshal(map[string]string{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestCountry_BatchesBranchesAndHeadquarters(t *testing.T) {
+	store := newFakeStore()
+	resp := query(t, store, `{
+		country(iso2: "pl") {
+			name
+			banks(first: 10) {
+				totalCount
+				nodes {
+					swiftCode
+					headquarter { swiftCode }
+					branches { swiftCode headquarter { swiftCode } }
+				}
+			}
+		}
+	}`)
+	assert.Empty(t, resp.Errors)
+
+	var data struct {
+		Country struct {
+			Name  string
+			Banks struct {
+				TotalCount int
+				Nodes      []struct {
+					SwiftCode   string
+					Headquarter *struct{ SwiftCode string }
+					Branches    []struct {
+						SwiftCode   string
+						Headquarter struct{ SwiftCode string }
+					}
+				}
+			}
+		}
+	}
+	assert.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Equal(t, "POLAND", data.Country.Name)
+	assert.Equal(t, 6, data.Country.Banks.TotalCount)
+	assert.Len(t, data.Country.Banks.Nodes, 6)
+
+	for _, n := range data.Country.Banks.Nodes {
+		switch n.SwiftCode {
+		case "AAAAPLPWXXX":
+			assert.Nil(t, n.Headquarter)
+			if assert.Len(t, n.Branches, 2) {
+				assert.Equal(t, "AAAAPLPWXXX", n.Branches[0].Headquarter.SwiftCode)
+			}
+		case "BBBBPLPW001":
+			if assert.NotNil(t, n.Headquarter) {
+				assert.Equal(t, "BBBBPLPWXXX", n.Headquarter.SwiftCode)
+			}
+			assert.Empty(t, n.Branches)
+		}
+	}
+
+	// one query per level, not one per headquarter or branch
+	assert.Equal(t, map[string]int{"Country": 1, "CountryBanks": 1, "Branches": 1, "Banks": 1}, store.calls)
+}
+
+func TestCountry_Pagination(t *testing.T) {
+	store := newFakeStore()
+
+	var codes []string
+	after := ""
+	for range 3 {
+		q := `{ country(iso2: "PL") { banks(first: 4` + after + `) { nodes { swiftCode } pageInfo { hasNextPage endCursor } } } }`
+		resp := query(t, store, q)
+		assert.Empty(t, resp.Errors)
+
+		var data struct {
+			Country struct {
+				Banks struct {
+					Nodes    []struct{ SwiftCode string }
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+				}
+			}
+		}
+		assert.NoError(t, json.Unmarshal(resp.Data, &data))
+		for _, n := range data.Country.Banks.Nodes {
+			codes = append(codes, n.SwiftCode)
+		}
+		if !data.Country.Banks.PageInfo.HasNextPage {
+			break
+		}
+		after = `, after: "` + data.Country.Banks.PageInfo.EndCursor + `"`
+	}
+
+	assert.Len(t, codes, 6)
+	assert.True(t, slices.IsSorted(codes))
+}
+
+func TestBanks_SharesCountryPages(t *testing.T) {
+	store := newFakeStore()
+	resp := query(t, store, `{
+		banks(swiftCodes: ["AAAAPLPWXXX", "AAAAPLPW001", "BBBBPLPWXXX"]) {
+			country { banks(first: 2) { nodes { swiftCode } } }
+			branches { country { banks(first: 2) { nodes { swiftCode } } } }
+		}
+	}`)
+	assert.Empty(t, resp.Errors)
+	assert.Contains(t, string(resp.Data), `"AAAAPLPW002"`)
+
+	// branches share the countries of their headquarters, the page is loaded once
+	assert.Equal(t, 1, store.calls["CountryBanks"])
+}
+
+func TestCostLimit(t *testing.T) {
+	store := &fakeStore{calls: map[string]int{}}
+	for i := range 600 {
+		store.banks = append(store.banks, model.Bank{
+			SwiftCode: fmt.Sprintf("B%03dPLPWXXX", i), Name: "Bank", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true,
+		})
+	}
+
+	// aliases multiply the work without going deeper
+	var q strings.Builder
+	q.WriteString("{")
+	for i := range 11 {
+		fmt.Fprintf(&q, ` c%d: country(iso2: "PL") { banks(first: 500) { nodes { swiftCode } } }`, i)
+	}
+	q.WriteString("}")
+
+	resp := query(t, store, q.String())
+	if assert.NotEmpty(t, resp.Errors) {
+		assert.Contains(t, resp.Errors[0].Message, "query loads more than 5000 banks")
+	}
+	assert.LessOrEqual(t, store.calls["CountryBanks"], 10, "no banks are loaded after the limit")
+}
+
+func TestLookupQuota(t *testing.T) {
+	h, err := graphql.Handler(newFakeStore())
+	assert.NoError(t, err)
+	router := gin.New()
+	policy := ratelimit.Policy{Name: "lookup", PerIP: ratelimit.Limit{DailyQuota: 8}}
+	router.POST("/graphql", ratelimit.Metered(ratelimit.NewMemoryStore(), policy), h)
+
+	post := func(q string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"query": q})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// every loaded bank counts, here the 6 banks of the page and nothing else
+	q := `{ country(iso2: "PL") { banks(first: 10) { nodes { swiftCode } } } }`
+	w := post(q)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Quota-Remaining"))
+	assert.Contains(t, w.Body.String(), `"cost":{"banks":6,"limit":5000}`)
+
+	w = post(q)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotContains(t, w.Body.String(), "AAAAPLPWXXX")
+}
+
+func TestBank(t *testing.T) {
+	store := newFakeStore()
+	resp := query(t, store, `{
+		bank(swiftCode: "aaaaplpw001") { name isHeadquarter country { iso2 } headquarter { branches { swiftCode } } }
+		missing: bank(swiftCode: "ZZZZPLPWXXX") { name }
+	}`)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"bank": {
+			"name": "Bank AAAA",
+			"isHeadquarter": false,
+			"country": {"iso2": "PL"},
+			"headquarter": {"branches": [{"swiftCode": "AAAAPLPW001"}, {"swiftCode": "AAAAPLPW002"}]}
+		},
+		"missing": null
+	}`, string(resp.Data))
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"invalid code", `{ bank(swiftCode: "AAAA") { name } }`, "swiftCode must have 11 characters"},
+		{"page size", `{ country(iso2: "PL") { banks(first: 0) { totalCount } } }`, "first must be between 1 and 500"},
+		{"cursor", `{ country(iso2: "PL") { banks(after: "nope") { totalCount } } }`, "invalid cursor"},
+		{"unknown field", `{ bank(swiftCode: "AAAAPLPWXXX") { iban } }`, `Cannot query field "iban" on type "Bank".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := query(t, newFakeStore(), tt.query)
+			if assert.Len(t, resp.Errors, 1) {
+				assert.Equal(t, tt.message, resp.Errors[0].Message)
+			}
+		})
+	}
+}
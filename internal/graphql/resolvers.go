@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	maxBatch        = 500
+)
+
+type queryResolver struct {
+	store Store
+}
+
+func (q *queryResolver) Bank(ctx context.Context, args struct{ SwiftCode string }) (*bankResolver, error) {
+	code, err := swiftCode(args.SwiftCode)
+	if err != nil {
+		return nil, err
+	}
+	banks, err := q.banks(ctx, []string{code})
+	if err != nil || len(banks) == 0 {
+		return nil, err
+	}
+	return banks[0], nil
+}
+
+func (q *queryResolver) Banks(ctx context.Context, args struct{ SwiftCodes []string }) ([]*bankResolver, error) {
+	if len(args.SwiftCodes) > maxBatch {
+		return nil, errors.New("too many SWIFT codes in one query")
+	}
+	codes := make([]string, 0, len(args.SwiftCodes))
+	for _, c := range args.SwiftCodes {
+		code, err := swiftCode(c)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return q.banks(ctx, codes)
+}
+
+func (q *queryResolver) banks(ctx context.Context, codes []string) ([]*bankResolver, error) {
+	banks, err := q.store.Banks(ctx, codes)
+	if err != nil {
+		return nil, databaseError(ctx, "Banks", err)
+	}
+	return newBankSet(q.store, banks).resolvers, nil
+}
+
+func (q *queryResolver) Country(ctx context.Context, args struct{ Iso2 string }) (*countryResolver, error) {
+	iso2 := strings.ToUpper(strings.TrimSpace(args.Iso2))
+	if len(iso2) != 2 {
+		return nil, errors.New("iso2 must have 2 letters")
+	}
+
+	name, total, err := q.store.Country(ctx, iso2)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, databaseError(ctx, "Country", err)
+	}
+
+	c := &countryResolver{store: q.store, iso2: iso2, name: name}
+	c.totalOnce.Do(func() { c.total = total })
+	return c, nil
+}
+
+// bankSet is a group of banks resolved together (one query result or one page). The first
+// headquarter or branches lookup loads them for every bank of the set with one query.
+type bankSet struct {
+	store     Store
+	resolvers []*bankResolver
+
+	branchesOnce sync.Once
+	branches     map[string][]model.Bank // BIC8 -> branches
+	branchesErr  error
+
+	hqOnce sync.Once
+	hq     map[string]*bankResolver // BIC8 -> headquarter
+	hqErr  error
+
+	// one per country, so the banks of a country are loaded once for the whole set
+	countriesMu sync.Mutex
+	countries   map[string]*countryResolver
+}
+
+func newBankSet(store Store, banks []model.Bank) *bankSet {
+	set := &bankSet{store: store, resolvers: make([]*bankResolver, 0, len(banks))}
+	for _, b := range banks {
+		set.resolvers = append(set.resolvers, &bankResolver{set: set, bank: b})
+	}
+	return set
+}
+
+func (s *bankSet) country(b model.Bank) *countryResolver {
+	s.countriesMu.Lock()
+	defer s.countriesMu.Unlock()
+	if s.countries == nil {
+		s.countries = map[string]*countryResolver{}
+	}
+	c, ok := s.countries[b.CountryCode]
+	if !ok {
+		c = &countryResolver{store: s.store, iso2: b.CountryCode, name: b.CountryName}
+		s.countries[b.CountryCode] = c
+	}
+	return c
+}
+
+func (s *bankSet) loadBranches(ctx context.Context) (map[string][]model.Bank, error) {
+	s.branchesOnce.Do(func() {
+		var hqs []string
+		for _, r := range s.resolvers {
+			if r.bank.IsHeadquarter {
+				hqs = append(hqs, r.bank.SwiftCode)
+			}
+		}
+		s.branches = map[string][]model.Bank{}
+		if len(hqs) == 0 {
+			return
+		}
+
+		branches, err := s.store.Branches(ctx, hqs)
+		if err != nil {
+			s.branchesErr = databaseError(ctx, "Branches", err)
+			return
+		}
+		for _, b := range branches {
+			s.branches[b.SwiftCode[:8]] = append(s.branches[b.SwiftCode[:8]], b)
+		}
+	})
+	return s.branches, s.branchesErr
+}
+
+func (s *bankSet) loadHeadquarters(ctx context.Context) (map[string]*bankResolver, error) {
+	s.hqOnce.Do(func() {
+		seen := map[string]bool{}
+		var codes []string
+		for _, r := range s.resolvers {
+			if r.bank.IsHeadquarter || len(r.bank.SwiftCode) < 8 {
+				continue
+			}
+			code := r.bank.SwiftCode[:8] + "XXX"
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+		s.hq = map[string]*bankResolver{}
+		if len(codes) == 0 {
+			return
+		}
+
+		banks, err := s.store.Banks(ctx, codes)
+		if err != nil {
+			s.hqErr = databaseError(ctx, "Banks", err)
+			return
+		}
+		// the headquarters form a set of their own, so their branches are batched too
+		for _, r := range newBankSet(s.store, banks).resolvers {
+			s.hq[r.bank.SwiftCode[:8]] = r
+		}
+	})
+	return s.hq, s.hqErr
+}
+
+type bankResolver struct {
+	set  *bankSet
+	bank model.Bank
+}
+
+func (b *bankResolver) SwiftCode() string   { return b.bank.SwiftCode }
+func (b *bankResolver) Name() string        { return b.bank.Name }
+func (b *bankResolver) Address() string     { return b.bank.Address }
+func (b *bankResolver) IsHeadquarter() bool { return b.bank.IsHeadquarter }
+
+func (b *bankResolver) Country() *countryResolver {
+	return b.set.country(b.bank)
+}
+
+func (b *bankResolver) Headquarter(ctx context.Context) (*bankResolver, error) {
+	if b.bank.IsHeadquarter {
+		return nil, nil
+	}
+	hq, err := b.set.loadHeadquarters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hq[b.bank.SwiftCode[:8]], nil
+}
+
+func (b *bankResolver) Branches(ctx context.Context) ([]*branchResolver, error) {
+	if !b.bank.IsHeadquarter {
+		return []*branchResolver{}, nil
+	}
+	branches, err := b.set.loadBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*branchResolver, 0, len(branches[b.bank.SwiftCode[:8]]))
+	for _, br := range branches[b.bank.SwiftCode[:8]] {
+		out = append(out, &branchResolver{hq: b, bank: br})
+	}
+	return out, nil
+}
+
+// a branch listed under its headquarter, which is already resolved
+type branchResolver struct {
+	hq   *bankResolver
+	bank model.Bank
+}
+
+func (b *branchResolver) SwiftCode() string { return b.bank.SwiftCode }
+func (b *branchResolver) Name() string      { return b.bank.Name }
+func (b *branchResolver) Address() string   { return b.bank.Address }
+
+func (b *branchResolver) Country() *countryResolver {
+	return b.hq.set.country(b.bank)
+}
+
+func (b *branchResolver) Headquarter() *bankResolver { return b.hq }
+
+type countryResolver struct {
+	store Store
+	iso2  string
+	name  string
+
+	// only loaded when totalCount is asked for
+	totalOnce sync.Once
+	total     int
+	totalErr  error
+
+	// pages by their arguments, shared by every bank of the set asking for the same page
+	pagesMu sync.Mutex
+	pages   map[pageArgsKey]*countryPage
+}
+
+type pageArgsKey struct {
+	first int32
+	after string
+}
+
+type countryPage struct {
+	once sync.Once
+	conn *connectionResolver
+	err  error
+}
+
+func (c *countryResolver) Iso2() string { return c.iso2 }
+func (c *countryResolver) Name() string { return c.name }
+
+type pageArgs struct {
+	First int32
+	After *string
+}
+
+func (c *countryResolver) Banks(ctx context.Context, args pageArgs) (*connectionResolver, error) {
+	if args.First < 1 || args.First > maxPageSize {
+		return nil, errors.New("first must be between 1 and 500")
+	}
+	var after string
+	if args.After != nil {
+		var err error
+		if after, err = decodeCursor(*args.After); err != nil {
+			return nil, err
+		}
+	}
+
+	c.pagesMu.Lock()
+	if c.pages == nil {
+		c.pages = map[pageArgsKey]*countryPage{}
+	}
+	key := pageArgsKey{first: args.First, after: after}
+	page, ok := c.pages[key]
+	if !ok {
+		page = &countryPage{}
+		c.pages[key] = page
+	}
+	c.pagesMu.Unlock()
+
+	page.once.Do(func() {
+		// one extra row tells whether there is a next page
+		banks, err := c.store.CountryBanks(ctx, c.iso2, after, int(args.First)+1)
+		if err != nil {
+			page.err = databaseError(ctx, "CountryBanks", err)
+			return
+		}
+		hasNext := len(banks) > int(args.First)
+		if hasNext {
+			banks = banks[:args.First]
+		}
+		page.conn = &connectionResolver{country: c, set: newBankSet(c.store, banks), hasNext: hasNext}
+	})
+	return page.conn, page.err
+}
+
+type connectionResolver struct {
+	country *countryResolver
+	set     *bankSet
+	hasNext bool
+}
+
+func (c *connectionResolver) Nodes() []*bankResolver { return c.set.resolvers }
+
+func (c *connectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	country := c.country
+	country.totalOnce.Do(func() {
+		_, total, err := country.store.Country(ctx, country.iso2)
+		if err != nil && !isNotFound(err) {
+			country.totalErr = databaseError(ctx, "Country", err)
+		}
+		country.total = total
+	})
+	return int32(country.total), country.totalErr
+}
+
+func (c *connectionResolver) PageInfo() *pageInfoResolver {
+	p := &pageInfoResolver{hasNext: c.hasNext}
+	if n := len(c.set.resolvers); n > 0 {
+		cursor := encodeCursor(c.set.resolvers[n-1].bank.SwiftCode)
+		p.endCursor = &cursor
+	}
+	return p
+}
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool  { return p.hasNext }
+func (p *pageInfoResolver) EndCursor() *string { return p.endCursor }
+
+// cursors are opaque to clients, internally they are the last SWIFT code of the page
+func encodeCursor(swiftCode string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("bank:" + swiftCode))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	code, ok := strings.CutPrefix(string(raw), "bank:")
+	if err != nil || !ok {
+		return "", errors.New("invalid cursor")
+	}
+	return code, nil
+}
+
+func swiftCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 11 {
+		return "", errors.New("swiftCode must have 11 characters")
+	}
+	return code, nil
+}
+
+func databaseError(ctx context.Context, op string, err error) error {
+	if isTooExpensive(err) {
+		return err
+	}
+	logging.FromContext(ctx).Error("GraphQL query failed", "operation", op, "error", err)
+	return errDatabase
+}
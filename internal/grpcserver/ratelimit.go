@@ -0,0 +1,111 @@
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/ratelimit"
+	swiftv1 "github.com/white67/swift_api/proto/swift/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadata identifies a client like the X-API-Key header of the REST API, only
+// keys issued in the policies' Keys are trusted
+const APIKeyMetadata = "x-api-key"
+
+// Limits are the rate limit policies of the SWIFT code service, the same ones as for
+// the REST routes so a client cannot get around them by switching protocols
+type Limits struct {
+	Store   ratelimit.Store  // nil disables rate limiting
+	Lookup  ratelimit.Policy // GetSwiftCode
+	Export  ratelimit.Policy // Export
+	Default ratelimit.Policy // every other method
+}
+
+// health checks and reflection are not limited, like /healthz and /openapi.json
+func (l Limits) policy(method string) (ratelimit.Policy, bool) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if l.Store == nil || !ok || service != swiftv1.SwiftCodeService_ServiceDesc.ServiceName {
+		return ratelimit.Policy{}, false
+	}
+	switch name {
+	case "GetSwiftCode":
+		return l.Lookup, true
+	case "Export":
+		return l.Export, true
+	}
+	return l.Default, true
+}
+
+// takes a token for the call, exceeded limits are ResourceExhausted with a retry-after header
+func (l Limits) take(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	policy, ok := l.policy(method)
+	if !ok {
+		return nil
+	}
+	key, limit := policy.Key(apiKey(ctx), peerIP(ctx))
+	if limit.Unlimited() {
+		return nil
+	}
+
+	res, err := l.Store.Take(ctx, key, limit)
+	if err != nil {
+		// do not block traffic when the shared store is down
+		logging.FromContext(ctx).Error("Rate limit store error", "policy", policy.Name, "error", err)
+		return nil
+	}
+	if res.Allowed {
+		return nil
+	}
+
+	setHeader(metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))))
+	if res.QuotaExceeded {
+		return status.Error(codes.ResourceExhausted, "Daily quota exceeded")
+	}
+	return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+}
+
+func (l Limits) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	setHeader := func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }
+	if err := l.take(ctx, info.FullMethod, setHeader); err != nil {
+		return nil, err
+	}
+	return next(ctx, req)
+}
+
+func (l Limits) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	if err := l.take(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return next(srv, ss)
+}
+
+func apiKey(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(APIKeyMetadata); len(keys) > 0 {
+			return keys[0]
+		}
+	}
+	return ""
+}
+
+// the IP of the caller, without the port so every connection of a client shares the bucket
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
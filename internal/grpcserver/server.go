@@ -0,0 +1,312 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/cache"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	swiftv1 "github.com/white67/swift_api/proto/swift/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Service implements swift.v1.SwiftCodeService on the same database layer as the REST handlers
+type Service struct {
+	swiftv1.UnimplementedSwiftCodeServiceServer
+
+	db    *sql.DB
+	cache *cache.Directory // may be nil
+}
+
+// New creates the gRPC server with the SWIFT code service, health checking and reflection.
+// The health status starts as NOT_SERVING, call SetServing once the data is loaded.
+func New(db *sql.DB, dir *cache.Directory, limits Limits) (*grpc.Server, *health.Server) {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryLogger, limits.unary),
+		grpc.ChainStreamInterceptor(streamLogger, limits.stream),
+	)
+
+	swiftv1.RegisterSwiftCodeServiceServer(server, &Service{db: db, cache: dir})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus(swiftv1.SwiftCodeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server, healthServer
+}
+
+// SetServing reports the server and the SWIFT code service as (not) ready
+func SetServing(h *health.Server, serving bool) {
+	s := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		s = healthpb.HealthCheckResponse_SERVING
+	}
+	h.SetServingStatus("", s)
+	h.SetServingStatus(swiftv1.SwiftCodeService_ServiceDesc.ServiceName, s)
+}
+
+func (s *Service) GetSwiftCode(ctx context.Context, req *swiftv1.GetSwiftCodeRequest) (*swiftv1.GetSwiftCodeResponse, error) {
+	code, err := swiftCode(req.GetSwiftCode())
+	if err != nil {
+		return nil, err
+	}
+
+	bank, err := database.GetBankBySwiftCodeContext(ctx, s.db, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "SWIFT code not found")
+	}
+	if err != nil {
+		return nil, internal(ctx, "GetSwiftCode", err)
+	}
+
+	resp := &swiftv1.GetSwiftCodeResponse{Bank: toProto(*bank)}
+	if bank.IsHeadquarter {
+		branches, err := database.GetBranchesForHeadquarterContext(ctx, s.db, code)
+		if err != nil {
+			return nil, internal(ctx, "GetSwiftCode", err)
+		}
+		resp.Branches = toProtoList(branches)
+	}
+	return resp, nil
+}
+
+func (s *Service) ListByCountry(ctx context.Context, req *swiftv1.ListByCountryRequest) (*swiftv1.ListByCountryResponse, error) {
+	country, err := countryCode(req.GetCountryIso2(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	banks, err := database.GetBanksByCountryContext(ctx, s.db, country)
+	if err != nil {
+		return nil, internal(ctx, "ListByCountry", err)
+	}
+	if len(banks) == 0 {
+		return nil, status.Error(codes.NotFound, "No banks found for given country code")
+	}
+
+	// like the REST response the country name is only listed once
+	resp := &swiftv1.ListByCountryResponse{CountryIso2: country, CountryName: banks[0].CountryName}
+	for _, b := range banks {
+		b.CountryName = ""
+		resp.SwiftCodes = append(resp.SwiftCodes, toProto(b))
+	}
+	return resp, nil
+}
+
+func (s *Service) ListBranches(ctx context.Context, req *swiftv1.ListBranchesRequest) (*swiftv1.ListBranchesResponse, error) {
+	code, err := swiftCode(req.GetSwiftCode())
+	if err != nil {
+		return nil, err
+	}
+	if !model.TypeHeadquarters(code) {
+		return nil, status.Error(codes.InvalidArgument, "swift_code must be a headquarter code ending in XXX")
+	}
+
+	branches, err := database.GetBranchesForHeadquarterContext(ctx, s.db, code)
+	if err != nil {
+		return nil, internal(ctx, "ListBranches", err)
+	}
+	return &swiftv1.ListBranchesResponse{Branches: toProtoList(branches)}, nil
+}
+
+func (s *Service) Create(ctx context.Context, req *swiftv1.CreateRequest) (*swiftv1.CreateResponse, error) {
+	if req.GetBank() == nil {
+		return nil, status.Error(codes.InvalidArgument, "bank is required")
+	}
+	bank := fromProto(req.GetBank())
+
+	code, err := swiftCode(bank.SwiftCode)
+	if err != nil {
+		return nil, err
+	}
+	country, err := countryCode(bank.CountryCode, false)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(bank.Name) == "" {
+		return nil, status.Error(codes.InvalidArgument, "bank_name is required")
+	}
+	bank.SwiftCode = code
+	bank.CountryCode = country
+	bank.CountryName = strings.ToUpper(bank.CountryName)
+
+	err = database.CreateBankContext(ctx, s.db, bank)
+	if errors.Is(err, database.ErrDuplicate) {
+		return nil, status.Error(codes.AlreadyExists, "SWIFT code already exists")
+	}
+	if err != nil {
+		return nil, internal(ctx, "Create", err)
+	}
+
+	s.cache.InvalidateBank(bank.SwiftCode, bank.CountryCode)
+	return &swiftv1.CreateResponse{}, nil
+}
+
+func (s *Service) Delete(ctx context.Context, req *swiftv1.DeleteRequest) (*swiftv1.DeleteResponse, error) {
+	code, err := swiftCode(req.GetSwiftCode())
+	if err != nil {
+		return nil, err
+	}
+
+	country, err := database.DeleteBankContext(ctx, s.db, code, time.Time{})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "SWIFT code not found")
+	}
+	if err != nil {
+		return nil, internal(ctx, "Delete", err)
+	}
+
+	if err := database.TouchDeletedContext(ctx, s.db, code, country); err != nil {
+		logging.FromContext(ctx).Error("Error when marking country and headquarter as changed",
+			"swift_code", code,
+			"country", country,
+			"error", err,
+		)
+	}
+	s.cache.InvalidateBank(code, country)
+	return &swiftv1.DeleteResponse{}, nil
+}
+
+func (s *Service) Export(req *swiftv1.ExportRequest, stream grpc.ServerStreamingServer[swiftv1.Bank]) error {
+	country, err := countryCode(req.GetCountryIso2(), true)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	err = database.StreamBanks(ctx, s.db, country, func(b model.Bank) error {
+		return stream.Send(toProto(b))
+	})
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		return internal(ctx, "Export", err)
+	}
+	return nil
+}
+
+func swiftCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 11 {
+		return "", status.Error(codes.InvalidArgument, "swift_code must have 11 characters")
+	}
+	return code, nil
+}
+
+func countryCode(country string, optional bool) (string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if optional && country == "" {
+		return "", nil
+	}
+	if len(country) != 2 {
+		return "", status.Error(codes.InvalidArgument, "country_iso2 must have 2 letters")
+	}
+	return country, nil
+}
+
+// logs the cause and hides it from the caller, like the REST handlers
+func internal(ctx context.Context, method string, err error) error {
+	logging.FromContext(ctx).Error("gRPC request failed", "method", method, "error", err)
+	return status.Error(codes.Internal, "database error")
+}
+
+func toProto(b model.Bank) *swiftv1.Bank {
+	return &swiftv1.Bank{
+		SwiftCode:     b.SwiftCode,
+		BankName:      b.Name,
+		Address:       b.Address,
+		CountryIso2:   b.CountryCode,
+		CountryName:   b.CountryName,
+		IsHeadquarter: b.IsHeadquarter,
+	}
+}
+
+func toProtoList(banks []model.Bank) []*swiftv1.Bank {
+	out := make([]*swiftv1.Bank, 0, len(banks))
+	for _, b := range banks {
+		out = append(out, toProto(b))
+	}
+	return out
+}
+
+// is_headquarter is derived from the code, as in the CSV import
+func fromProto(b *swiftv1.Bank) model.Bank {
+	code := strings.ToUpper(b.GetSwiftCode())
+	return model.Bank{
+		SwiftCode:     code,
+		Name:          b.GetBankName(),
+		Address:       b.GetAddress(),
+		CountryCode:   b.GetCountryIso2(),
+		CountryName:   b.GetCountryName(),
+		IsHeadquarter: len(code) == 11 && model.TypeHeadquarters(code),
+	}
+}
+
+// gives every RPC a request logger (request ID from x-request-id metadata) and logs it once done
+func unaryLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	ctx, logger := requestLogger(ctx)
+	start := time.Now()
+	resp, err := next(ctx, req)
+	logRPC(logger, info.FullMethod, start, err)
+	return resp, err
+}
+
+func streamLogger(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	ctx, logger := requestLogger(ss.Context())
+	start := time.Now()
+	err := next(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+	logRPC(logger, info.FullMethod, start, err)
+	return err
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context { return s.ctx }
+
+func requestLogger(ctx context.Context) (context.Context, *slog.Logger) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(strings.ToLower(logging.RequestIDHeader)); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	logger := slog.Default()
+	if requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+	return logging.WithLogger(ctx, logger), logger
+}
+
+func logRPC(logger *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	}
+	logger.LogAttrs(context.Background(), level, "rpc",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
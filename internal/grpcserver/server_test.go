@@ -0,0 +1,207 @@
+package grpcserver_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/grpcserver"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/ratelimit"
+	swiftv1 "github.com/white67/swift_api/proto/swift/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var (
+	testDB *sql.DB
+	conn   *grpc.ClientConn
+)
+
+func TestMain(m *testing.M) {
+	testDB = config.ConnectToDB()
+	config.InitSchema(testDB)
+
+	testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'GRPC%'")
+	for _, bank := range []model.Bank{
+		{Address: "gRPC Street 1", Name: "gRPC Bank", CountryCode: "GR", CountryName: "GREECE", IsHeadquarter: true, SwiftCode: "GRPCGRAAXXX"},
+		{Address: "gRPC Street 2", Name: "gRPC Bank Branch", CountryCode: "GR", CountryName: "GREECE", IsHeadquarter: false, SwiftCode: "GRPCGRAA001"},
+	} {
+		database.InsertBank(testDB, bank)
+	}
+
+	// in-memory listener instead of a TCP port
+	lis := bufconn.Listen(1 << 20)
+	server, health := grpcserver.New(testDB, nil, grpcserver.Limits{})
+	grpcserver.SetServing(health, true)
+	go server.Serve(lis)
+
+	var err error
+	conn, err = grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	conn.Close()
+	server.Stop()
+	testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'GRPC%'")
+	testDB.Close()
+
+	os.Exit(code)
+}
+
+func TestGetSwiftCode(t *testing.T) {
+	client := swiftv1.NewSwiftCodeServiceClient(conn)
+
+	resp, err := client.GetSwiftCode(context.Background(), &swiftv1.GetSwiftCodeRequest{SwiftCode: "grpcgraaxxx"})
+	assert.NoError(t, err)
+	assert.Equal(t, "gRPC Bank", resp.GetBank().GetBankName())
+	assert.True(t, resp.GetBank().GetIsHeadquarter())
+	if assert.Len(t, resp.GetBranches(), 1) {
+		assert.Equal(t, "GRPCGRAA001", resp.GetBranches()[0].GetSwiftCode())
+	}
+
+	_, err = client.GetSwiftCode(context.Background(), &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAA999"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetSwiftCode(context.Background(), &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPC"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListByCountryAndBranches(t *testing.T) {
+	client := swiftv1.NewSwiftCodeServiceClient(conn)
+
+	country, err := client.ListByCountry(context.Background(), &swiftv1.ListByCountryRequest{CountryIso2: "gr"})
+	assert.NoError(t, err)
+	assert.Equal(t, "GREECE", country.GetCountryName())
+	assert.Len(t, country.GetSwiftCodes(), 2)
+
+	branches, err := client.ListBranches(context.Background(), &swiftv1.ListBranchesRequest{SwiftCode: "GRPCGRAAXXX"})
+	assert.NoError(t, err)
+	assert.Len(t, branches.GetBranches(), 1)
+
+	_, err = client.ListBranches(context.Background(), &swiftv1.ListBranchesRequest{SwiftCode: "GRPCGRAA001"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateDelete(t *testing.T) {
+	client := swiftv1.NewSwiftCodeServiceClient(conn)
+	ctx := context.Background()
+
+	bank := &swiftv1.Bank{SwiftCode: "GRPCGRAA002", BankName: "gRPC Bank Branch 2", Address: "gRPC Street 3", CountryIso2: "GR", CountryName: "Greece"}
+	_, err := client.Create(ctx, &swiftv1.CreateRequest{Bank: bank})
+	assert.NoError(t, err)
+
+	_, err = client.Create(ctx, &swiftv1.CreateRequest{Bank: bank})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	stored, err := client.GetSwiftCode(ctx, &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAA002"})
+	assert.NoError(t, err)
+	assert.Equal(t, "GREECE", stored.GetBank().GetCountryName())
+	assert.False(t, stored.GetBank().GetIsHeadquarter())
+
+	_, err = client.Delete(ctx, &swiftv1.DeleteRequest{SwiftCode: "GRPCGRAA002"})
+	assert.NoError(t, err)
+	_, err = client.Delete(ctx, &swiftv1.DeleteRequest{SwiftCode: "GRPCGRAA002"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestExport(t *testing.T) {
+	client := swiftv1.NewSwiftCodeServiceClient(conn)
+
+	stream, err := client.Export(context.Background(), &swiftv1.ExportRequest{CountryIso2: "GR"})
+	assert.NoError(t, err)
+
+	var codes []string
+	for {
+		bank, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		codes = append(codes, bank.GetSwiftCode())
+	}
+	assert.Equal(t, []string{"GRPCGRAA001", "GRPCGRAAXXX"}, codes)
+}
+
+func TestHealth(t *testing.T) {
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: swiftv1.SwiftCodeService_ServiceDesc.ServiceName,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestRateLimit(t *testing.T) {
+	keys, _ := auth.ParseKeys("test=test-key-0123456789")
+	lis := bufconn.Listen(1 << 20)
+	server, health := grpcserver.New(testDB, nil, grpcserver.Limits{
+		Store:   ratelimit.NewMemoryStore(),
+		Lookup:  ratelimit.Policy{Name: "lookup", PerIP: ratelimit.Limit{Rate: 0.001, Burst: 1}, Keys: keys},
+		Export:  ratelimit.Policy{Name: "export", PerIP: ratelimit.Limit{DailyQuota: 1}},
+		Default: ratelimit.Policy{Name: "default", PerIP: ratelimit.Limit{Rate: 0.001, Burst: 1}},
+	})
+	grpcserver.SetServing(health, true)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	limited, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	defer limited.Close()
+	client := swiftv1.NewSwiftCodeServiceClient(limited)
+	ctx := context.Background()
+
+	_, err = client.GetSwiftCode(ctx, &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAAXXX"})
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.GetSwiftCode(ctx, &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAAXXX"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+
+	// streams are limited too, exports by their own daily quota
+	stream, err := client.Export(ctx, &swiftv1.ExportRequest{CountryIso2: "GR"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+	stream, err = client.Export(ctx, &swiftv1.ExportRequest{CountryIso2: "GR"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// issued API keys have their own (here unlimited) buckets, made up keys share the one of the IP
+	keyed := metadata.AppendToOutgoingContext(ctx, grpcserver.APIKeyMetadata, "test-key-0123456789")
+	_, err = client.GetSwiftCode(keyed, &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAAXXX"})
+	assert.NoError(t, err)
+	unknown := metadata.AppendToOutgoingContext(ctx, grpcserver.APIKeyMetadata, "made-up-key")
+	_, err = client.GetSwiftCode(unknown, &swiftv1.GetSwiftCodeRequest{SwiftCode: "GRPCGRAAXXX"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// health checks are not limited
+	for i := 0; i < 3; i++ {
+		_, err = healthpb.NewHealthClient(limited).Check(ctx, &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+	}
+}
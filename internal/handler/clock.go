@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/calendar"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+)
+
+// local business hours of every bank, nil when not configured
+var bankHours *calendar.Hours
+
+func SetBusinessHours(h *calendar.Hours) {
+	bankHours = h
+}
+
+type businessHours struct {
+	Open   string `json:"open"`
+	Close  string `json:"close"`
+	Cutoff string `json:"cutoff"`
+}
+
+type clockResponse struct {
+	SwiftCode     string        `json:"swiftCode"`
+	CountryCode   string        `json:"countryISO2"`
+	TimeZone      string        `json:"timeZone"`
+	LocalTime     string        `json:"localTime"`
+	BusinessDay   bool          `json:"businessDay"`
+	Holiday       string        `json:"holiday,omitempty"`
+	Open          bool          `json:"open"`
+	BusinessHours businessHours `json:"businessHours"`
+	NextCutoff    time.Time     `json:"nextCutoff"`
+}
+
+// local time of a bank, whether it is open and when the next payment cutoff is.
+// at (RFC 3339) evaluates another instant than now.
+func GetSwiftCodeClock(c *gin.Context) {
+	if bankHours == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Business hours are not configured"})
+		return
+	}
+
+	now := time.Now()
+	if at, ok := c.GetQuery("at"); ok {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "at must be an RFC 3339 time, e.g. 2026-05-04T10:00:00Z"})
+			return
+		}
+		now = t
+	}
+
+	ctx := c.Request.Context()
+	swiftCode := strings.ToUpper(c.Param("swiftCode"))
+	bank, err := database.GetBankBySwiftCodeContext(ctx, config.GetDB(), swiftCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "SWIFT code not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when fetching a bank", "swift_code", swiftCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+
+	if bank.TimeZone == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "The time zone of this bank is unknown"})
+		return
+	}
+	loc, err := time.LoadLocation(bank.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Unknown time zone " + bank.TimeZone})
+		return
+	}
+
+	cal, err := holidayCalendar(ctx, bank.CountryCode, now)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when loading holidays", "country", bank.CountryCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+	cal.Hours = *bankHours
+
+	clock, err := cal.At(now, loc, bank.CountryCode)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "No business day within a year"})
+		return
+	}
+
+	hours := cal.Hours
+	c.JSON(http.StatusOK, clockResponse{
+		SwiftCode:     bank.SwiftCode,
+		CountryCode:   bank.CountryCode,
+		TimeZone:      bank.TimeZone,
+		LocalTime:     clock.LocalTime.Format(time.RFC3339),
+		BusinessDay:   clock.BusinessDay,
+		Holiday:       clock.Holiday,
+		Open:          clock.Open,
+		BusinessHours: businessHours{Open: hours.Open.String(), Close: hours.Close.String(), Cutoff: hours.Cutoff.String()},
+		NextCutoff:    clock.NextCutoff.UTC(),
+	})
+}
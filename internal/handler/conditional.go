@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/tracing"
+)
+
+const tracerName = "github.com/white67/swift_api/internal/handler"
+
+// a rendered response together with its validators
+type resource struct {
+	body         gin.H
+	etag         string
+	lastModified time.Time
+	version      time.Time // updated_at of the bank row, used for optimistic locking
+}
+
+func newResource(body gin.H, lastModified time.Time) (*resource, error) {
+	etag, err := computeETag(body)
+	if err != nil {
+		return nil, err
+	}
+	return &resource{body: body, etag: etag, lastModified: lastModified.UTC().Truncate(time.Second)}, nil
+}
+
+// strong ETag derived from the response content
+func computeETag(body any) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// writes the resource or 304 if the client copy is still fresh
+func writeResource(c *gin.Context, res *resource) {
+	c.Header("ETag", res.etag)
+	if !res.lastModified.IsZero() {
+		c.Header("Last-Modified", res.lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, res) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	_, span := tracing.Start(c.Request.Context(), tracerName, "render")
+	c.JSON(http.StatusOK, res.body)
+	span.End()
+}
+
+// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2)
+func notModified(r *http.Request, res *resource) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, res.etag, true)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !res.lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !res.lastModified.After(t)
+	}
+
+	return false
+}
+
+// checks If-Match before a write, current is nil when the resource does not exist.
+// Responds with 412 and returns false when the precondition fails.
+func checkIfMatch(c *gin.Context, current *resource) bool {
+	im := c.GetHeader("If-Match")
+	if im == "" {
+		return true
+	}
+
+	if current != nil && etagMatches(im, current.etag, false) {
+		return true
+	}
+
+	c.JSON(http.StatusPreconditionFailed, gin.H{"message": "SWIFT code has been modified by another request"})
+	return false
+}
+
+// header is a comma separated list of entity tags or "*"
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
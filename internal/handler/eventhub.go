@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/model"
+)
+
+const eventBuffer = 16 // batches a stream may fall behind before it is dropped
+
+var errTooManyStreams = errors.New("too many open event streams")
+
+// eventHub polls the events table once for all open /v1/events streams and hands
+// every new event to each of them, so the database sees one query per interval
+// however many streams are open. It only runs while streams are subscribed.
+type eventHub struct {
+	mu      sync.Mutex
+	subs    map[*eventSub]struct{}
+	clients map[string]int // open streams per client
+	last    int64          // ID of the last event handed out
+	running bool
+}
+
+type eventSub struct {
+	client string
+	events chan []model.Event // closed when the stream fell too far behind
+}
+
+var hub = &eventHub{subs: map[*eventSub]struct{}{}, clients: map[string]int{}}
+
+// subscribe registers a stream of the client, every event after the returned ID is sent to it
+func (h *eventHub) subscribe(ctx context.Context, db *sql.DB, client string, limit int) (*eventSub, int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if limit > 0 && h.clients[client] >= limit {
+		return nil, 0, errTooManyStreams
+	}
+	if !h.running {
+		last, err := database.LatestEventIDContext(ctx, db)
+		if err != nil {
+			return nil, 0, err
+		}
+		h.last = last
+		h.running = true
+		go h.run(db)
+	}
+
+	s := &eventSub{client: client, events: make(chan []model.Event, eventBuffer)}
+	h.subs[s] = struct{}{}
+	h.clients[s.client]++
+	return s, h.last, nil
+}
+
+func (h *eventHub) unsubscribe(s *eventSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subs, s)
+	if h.clients[s.client]--; h.clients[s.client] <= 0 {
+		delete(h.clients, s.client)
+	}
+}
+
+func (h *eventHub) run(db *sql.DB) {
+	poll := time.NewTicker(eventPollInterval)
+	defer poll.Stop()
+
+	for {
+		// only run changes last, it can be read without holding the lock during the query
+		h.mu.Lock()
+		after := h.last
+		h.mu.Unlock()
+
+		events, err := database.ListEventsContext(context.Background(), db, database.EventFilter{AfterID: after}, eventBatch)
+		if err != nil {
+			slog.Error("Error when reading events", "error", err)
+		}
+
+		h.mu.Lock()
+		if len(events) > 0 {
+			h.last = events[len(events)-1].ID
+			for s := range h.subs {
+				select {
+				case s.events <- events:
+				default:
+					// too slow, the client reconnects with Last-Event-ID and catches up
+					close(s.events)
+					delete(h.subs, s)
+				}
+			}
+		}
+		if len(h.subs) == 0 {
+			h.running = false
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+
+		if len(events) == eventBatch {
+			continue // catching up, do not wait
+		}
+		select {
+		case <-poll.C:
+		case <-streamsClosed:
+			h.mu.Lock()
+			h.running = false
+			h.mu.Unlock()
+			return
+		}
+	}
+}
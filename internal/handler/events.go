@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/ratelimit"
+)
+
+const (
+	eventPollInterval = time.Second
+	eventHeartbeat    = 15 * time.Second
+	eventBatch        = 100
+	eventRetryMs      = 3000 // reconnect delay suggested to EventSource clients
+)
+
+var (
+	streamsClosed    = make(chan struct{})
+	closeStreamsOnce sync.Once
+
+	maxEventStreams = 5
+	// open streams are counted per issued API key or IP, like the rate limits
+	eventClients = ratelimit.Policy{Name: "events"}
+)
+
+// CloseEventStreams ends every open /v1/events stream, http.Server.Shutdown waits
+// for active requests and these would never finish on their own
+func CloseEventStreams() {
+	closeStreamsOnce.Do(func() { close(streamsClosed) })
+}
+
+// SetMaxEventStreams caps the open /v1/events streams of one client, 0 removes the cap
+func SetMaxEventStreams(n int) {
+	maxEventStreams = n
+}
+
+// SetEventClientKeys sets the issued API keys, streams sent with another key are
+// counted per IP
+func SetEventClientKeys(keys *auth.Keys) {
+	eventClients.Keys = keys
+}
+
+// streams directory change events as Server-Sent Events. Without Last-Event-ID
+// (header, or lastEventId for the first EventSource request) only new events are sent.
+// New events come from the shared hub, a resumed stream reads the events it missed
+// from the database first.
+func StreamEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	db := config.GetDB()
+
+	filter := database.EventFilter{
+		CountryCode: strings.ToUpper(c.Query("country")),
+		SwiftCode:   strings.ToUpper(c.Query("swiftCode")),
+	}
+	if n := len(filter.CountryCode); n != 0 && n != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "country must have 2 letters"})
+		return
+	}
+	if n := len(filter.SwiftCode); n != 0 && n != 8 && n != 11 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "swiftCode must have 8 or 11 characters"})
+		return
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	if lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid Last-Event-ID"})
+			return
+		}
+		filter.AfterID = id
+	}
+
+	client, _ := eventClients.Key(c.GetHeader(ratelimit.APIKeyHeader), c.ClientIP())
+	sub, hubID, err := hub.subscribe(ctx, db, client, maxEventStreams)
+	if errors.Is(err, errTooManyStreams) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Too many open event streams"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when reading the latest event", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to open event stream"})
+		return
+	}
+	defer hub.unsubscribe(sub)
+	if lastID == "" {
+		filter.AfterID = hubID
+	}
+
+	// the stream outlives the server's WriteTimeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // no proxy buffering (nginx)
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventRetryMs)
+	c.Writer.Flush()
+
+	// events up to the hub's position are not sent by the hub, events after it may come
+	// twice and are skipped by the filter
+	for filter.AfterID < hubID {
+		events, err := database.ListEventsContext(ctx, db, filter, eventBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).Error("Error when reading events", "error", err)
+			}
+			return
+		}
+		if _, err := sendEvents(c.Writer, &filter, events); err != nil {
+			return
+		}
+		if len(events) < eventBatch {
+			break
+		}
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-streamsClosed:
+			return
+		case events, ok := <-sub.events:
+			if !ok {
+				return // fell behind, the client resumes with Last-Event-ID
+			}
+			sent, err := sendEvents(c.Writer, &filter, events)
+			if err != nil {
+				return
+			}
+			if sent {
+				heartbeat.Reset(eventHeartbeat)
+			}
+		case <-heartbeat.C:
+			// comment line, keeps idle connections open through proxies
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writes the events passing the filter and moves the filter past them
+func sendEvents(w gin.ResponseWriter, filter *database.EventFilter, events []model.Event) (sent bool, err error) {
+	for _, e := range events {
+		if !filter.Matches(e) {
+			continue
+		}
+		err := sse.Encode(w, sse.Event{Id: strconv.FormatInt(e.ID, 10), Event: e.Type, Data: e})
+		if err != nil {
+			return sent, err
+		}
+		filter.AfterID = e.ID
+		sent = true
+	}
+	if sent {
+		w.Flush()
+	}
+	return sent, nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/export"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// streams the directory as csv (default), jsonl or json, optionally filtered by ?country=
+func ExportSwiftCodes(c *gin.Context) {
+	format, err := export.LookupFormat(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	country := strings.ToUpper(c.Query("country"))
+	if country != "" && len(country) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Country code must have 2 letters"})
+		return
+	}
+
+	filename := "swift_codes"
+	if country != "" {
+		filename += "_" + country
+	}
+
+	// large or slow exports outlive the server's WriteTimeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", format.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format.Extension))
+	c.Status(http.StatusOK)
+
+	w := format.New(c.Writer)
+	err = database.StreamBanks(c.Request.Context(), config.GetDB(), country, func(b model.Bank) error {
+		if err := w.Write(b); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// headers are already sent, the client sees a truncated body
+		logging.FromContext(c.Request.Context()).Error("Export failed", "country", country, "format", format.Name, "error", err)
+		return
+	}
+
+	if err := w.Close(); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Export failed", "country", country, "format", format.Name, "error", err)
+	}
+}
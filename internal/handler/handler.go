@@ -1,80 +1,138 @@
 package handler
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/cache"
 	"github.com/white67/swift_api/internal/config"
 	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
 	"github.com/white67/swift_api/internal/model"
 )
 
+// lookup cache, nil when caching is disabled
+var directoryCache *cache.Directory
+
+func SetCache(c *cache.Directory) {
+	directoryCache = c
+}
+
+func GetCache() *cache.Directory {
+	return directoryCache
+}
+
 func GetSwiftCodeDetails(c *gin.Context) {
 	swiftCode := c.Param("swiftCode")
 
-	db := config.GetDB()
-
-	bank, err := database.GetBankBySwiftCode(db, swiftCode)
-	if err != nil {
+	res, err := bankResource(c.Request.Context(), config.GetDB(), swiftCode)
+	if errors.Is(err, errNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "SWIFT code not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching branches"})
+		return
+	}
+
+	writeResource(c, res)
+}
+
+var errNotFound = errors.New("not found")
+
+// loads the response for a single code, from the cache when possible
+func bankResource(ctx context.Context, db *sql.DB, swiftCode string) (*resource, error) {
+	if directoryCache != nil {
+		if cached, ok := directoryCache.Codes.Get(swiftCode); ok {
+			return cached.(*resource), nil
+		}
+	}
+	gen := directoryCache.Generation()
+
+	bank, err := database.GetBankBySwiftCodeContext(ctx, db, swiftCode)
+	if err != nil {
+		return nil, errNotFound
+	}
+
+	response := gin.H{
+		"address":       bank.Address,
+		"bankName":      bank.Name,
+		"countryISO2":   bank.CountryCode,
+		"countryName":   bank.CountryName,
+		"isHeadquarter": bank.IsHeadquarter,
+		"swiftCode":     bank.SwiftCode,
+	}
+	if bank.TownName != "" {
+		response["townName"] = bank.TownName
+	}
+	if bank.Location != nil {
+		response["location"] = bank.Location
+	}
+	if bank.TimeZone != "" {
+		response["timeZone"] = bank.TimeZone
+	}
+	if bank.PostalAddress != nil {
+		response["postalAddress"] = bank.PostalAddress
+	}
+	if bank.ScreeningStatus != "" {
+		response["screeningStatus"] = bank.ScreeningStatus
+	}
+	lastModified := bank.UpdatedAt
 
 	if bank.IsHeadquarter {
-		branches, err := database.GetBranchesForHeadquarter(db, bank.SwiftCode)
+		branches, err := database.GetBranchesForHeadquarterContext(ctx, db, bank.SwiftCode)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching branches"})
-			return
+			return nil, err
+		}
+		response["branches"] = branches
+
+		for _, branch := range branches {
+			if branch.UpdatedAt.After(lastModified) {
+				lastModified = branch.UpdatedAt
+			}
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"address":       bank.Address,
-			"bankName":      bank.Name,
-			"countryISO2":   bank.CountryCode,
-			"countryName":   bank.CountryName,
-			"isHeadquarter": bank.IsHeadquarter,
-			"swiftCode":     bank.SwiftCode,
-			"branches":      branches,
-		})
-	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"address":       bank.Address,
-			"bankName":      bank.Name,
-			"countryISO2":   bank.CountryCode,
-			"countryName":   bank.CountryName,
-			"isHeadquarter": bank.IsHeadquarter,
-			"swiftCode":     bank.SwiftCode,
-		})
 	}
+
+	res, err := newResource(response, lastModified)
+	if err != nil {
+		return nil, err
+	}
+	res.version = bank.UpdatedAt
+
+	directoryCache.SetCode(swiftCode, res, gen)
+	return res, nil
 }
 
 func GetCountryDetails(c *gin.Context) {
 	countryCode := c.Param("countryISO2code")
+
+	if directoryCache != nil {
+		if cached, ok := directoryCache.Countries.Get(countryCode); ok {
+			writeResource(c, cached.(*resource))
+			return
+		}
+	}
+	gen := directoryCache.Generation()
+
 	db := config.GetDB()
+	ctx := c.Request.Context()
 
-	rows, err := db.Query(`
-		SELECT bank_name, address, country_code, country_name, is_headquarter, swift_code
-		FROM banks
-		WHERE country_code = $1
-	`, countryCode)
+	banks, err := database.GetBanksByCountryContext(ctx, db, countryCode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database query error"})
 		return
 	}
-	defer rows.Close()
 
-	var banks []model.Bank
+	// the country name is only listed once
 	var countryName string
-
-	for rows.Next() {
-		var b model.Bank
-		err := rows.Scan(&b.Name, &b.Address, &b.CountryCode, &countryName, &b.IsHeadquarter, &b.SwiftCode)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning row"})
-			return
-		}
-		banks = append(banks, b)
+	for i := range banks {
+		countryName = banks[i].CountryName
+		banks[i].CountryName = ""
 	}
 
 	if len(banks) == 0 {
@@ -82,13 +140,25 @@ func GetCountryDetails(c *gin.Context) {
 		return
 	}
 
-	response := gin.H{
+	lastModified, err := database.GetCountryLastModifiedContext(ctx, db, countryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database query error"})
+		return
+	}
+
+	res, err := newResource(gin.H{
 		"countryISO2": countryCode,
 		"countryName": countryName,
 		"swiftCodes":  banks,
+	}, lastModified)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error rendering response"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	directoryCache.SetCountry(countryCode, res, gen)
+
+	writeResource(c, res)
 }
 
 func AddSwiftCode(c *gin.Context) {
@@ -100,44 +170,153 @@ func AddSwiftCode(c *gin.Context) {
 
 	bank.CountryCode = strings.ToUpper(bank.CountryCode)
 	bank.CountryName = strings.ToUpper(bank.CountryName)
+	if bank.TimeZone != "" && !model.IsTimeZone(bank.TimeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "timeZone must be an IANA time zone such as Europe/Warsaw"})
+		return
+	}
 
-	db := config.GetDB()
-	_, err := db.Exec(`
-		INSERT INTO banks (address, bank_name, country_code, country_name, is_headquarter, swift_code)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`,
-		bank.Address,
-		bank.Name,
-		bank.CountryCode,
-		bank.CountryName,
-		bank.IsHeadquarter,
-		bank.SwiftCode,
-	)
-
+	err := database.CreateBankContext(c.Request.Context(), config.GetDB(), bank)
+	if errors.Is(err, database.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"message": "SWIFT code already exists"})
+		return
+	}
 	if err != nil {
-		log.Printf("DB error: %v", err)
+		logging.FromContext(c.Request.Context()).Error("Error when inserting new data",
+			"swift_code", bank.SwiftCode,
+			"error", err,
+		)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to insert SWIFT code"})
 		return
 	}
 
+	directoryCache.InvalidateBank(bank.SwiftCode, bank.CountryCode)
+
 	c.JSON(http.StatusOK, gin.H{"message": "SWIFT code successfully added"})
 }
 
-func DeleteSwiftCode(c *gin.Context) {
+// replaces an existing entry, If-Match protects against overwriting someone else's edit
+func UpdateSwiftCode(c *gin.Context) {
 	swiftCode := c.Param("swiftCode")
 
+	var bank model.Bank
+	if err := c.ShouldBindJSON(&bank); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+	if bank.SwiftCode != "" && bank.SwiftCode != swiftCode {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "SWIFT code in body does not match the URL"})
+		return
+	}
+	bank.SwiftCode = swiftCode
+	if bank.TimeZone != "" && !model.IsTimeZone(bank.TimeZone) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "timeZone must be an IANA time zone such as Europe/Warsaw"})
+		return
+	}
+
 	db := config.GetDB()
-	result, err := db.Exec("DELETE FROM banks WHERE swift_code = $1", swiftCode)
+	ctx := c.Request.Context()
+
+	current, err := bankResource(ctx, db, swiftCode)
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "SWIFT code not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete SWIFT code"})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update SWIFT code"})
 		return
 	}
+	if !checkIfMatch(c, current) {
+		return
+	}
+
+	// only update the row we checked the ETag against
+	var version time.Time
+	if c.GetHeader("If-Match") != "" {
+		version = current.version
+	}
+
+	oldCountry, _ := current.body["countryISO2"].(string)
+
+	logger := logging.FromContext(c.Request.Context()).With("swift_code", swiftCode)
+
+	updated, err := database.UpdateBankContext(ctx, db, bank, version)
+	if err != nil {
+		logger.Error("Error when updating data", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update SWIFT code"})
+		return
+	}
+
+	directoryCache.InvalidateBank(swiftCode, oldCountry)
+	directoryCache.InvalidateBank(swiftCode, bank.CountryCode)
+
+	if updated == 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"message": "SWIFT code has been modified by another request"})
+		return
+	}
+
+	if !strings.EqualFold(oldCountry, bank.CountryCode) {
+		if err := database.TouchCountryContext(ctx, db, oldCountry); err != nil {
+			logger.Error("Error when marking country as changed", "country", oldCountry, "error", err)
+		}
+	}
+
+	if res, err := bankResource(ctx, db, swiftCode); err == nil {
+		c.Header("ETag", res.etag)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "SWIFT code successfully updated"})
+}
+
+func DeleteSwiftCode(c *gin.Context) {
+	swiftCode := c.Param("swiftCode")
+
+	db := config.GetDB()
+	ctx := c.Request.Context()
+
+	var version time.Time
+	if c.GetHeader("If-Match") != "" {
+		current, err := bankResource(ctx, db, swiftCode)
+		if err != nil && !errors.Is(err, errNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete SWIFT code"})
+			return
+		}
+		if !checkIfMatch(c, current) {
+			return
+		}
+		version = current.version
+	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
+	countryCode, err := database.DeleteBankContext(ctx, db, swiftCode, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !version.IsZero() {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"message": "SWIFT code has been modified by another request"})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"message": "SWIFT code not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete SWIFT code"})
+		return
+	}
+
+	if err := database.TouchDeletedContext(ctx, db, swiftCode, countryCode); err != nil {
+		logging.FromContext(ctx).Error("Error when marking country and headquarter as changed",
+			"swift_code", swiftCode,
+			"country", countryCode,
+			"error", err,
+		)
+	}
+
+	directoryCache.InvalidateBank(swiftCode, countryCode)
 
 	c.JSON(http.StatusOK, gin.H{"message": "SWIFT code successfully deleted"})
 }
+
+func GetCacheStats(c *gin.Context) {
+	if directoryCache == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "caches": directoryCache.Stats()})
+}
@@ -2,23 +2,38 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/calendar"
 	"github.com/white67/swift_api/internal/config"
 	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/geo"
 	"github.com/white67/swift_api/internal/handler"
+	"github.com/white67/swift_api/internal/iban"
 	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+	"github.com/white67/swift_api/internal/ratelimit"
+	"github.com/white67/swift_api/internal/screening"
 )
 
 var testDB *sql.DB
 
+// admin routes of setupRouter accept the key of "test-admin"
+var adminKeys, _ = auth.ParseKeys("test-admin=test-admin-key-0123456789")
+
+const adminAuth = "Bearer test-admin-key-0123456789"
+
 func TestMain(m *testing.M) {
 	// Use test mode for Gin
 	gin.SetMode(gin.TestMode)
@@ -79,10 +94,32 @@ func seedTestDatabase() {
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	admin := auth.Middleware(adminKeys)
+	router.GET("/v1/swift-codes/nearby", handler.NearbySwiftCodes)
 	router.GET("/v1/swift-codes/:swiftCode", handler.GetSwiftCodeDetails)
+	router.GET("/v1/swift-codes/:swiftCode/clock", handler.GetSwiftCodeClock)
+	router.GET("/v1/swift-codes/:swiftCode/business-days/:date", handler.GetSwiftCodeBusinessDay)
+	router.GET("/v1/holidays/:countryISO2code", handler.ListHolidays)
+	router.GET("/v1/business-days/:countryISO2code/:date", handler.GetBusinessDay)
 	router.GET("/v1/swift-codes/country/:countryISO2code", handler.GetCountryDetails)
 	router.POST("/v1/swift-codes", handler.AddSwiftCode)
+	router.POST("/v1/swift-codes/lookup", handler.BatchLookupSwiftCodes)
+	router.PUT("/v1/swift-codes/:swiftCode", handler.UpdateSwiftCode)
 	router.DELETE("/v1/swift-codes/:swiftcode", handler.DeleteSwiftCode)
+	router.GET("/healthz", handler.Healthz)
+	router.GET("/readyz", handler.Readyz)
+	router.GET("/v1/iban/:iban/validate", handler.ValidateIBAN)
+	router.GET("/v1/identifiers/:scheme/:id", handler.GetBankByIdentifier)
+	router.GET("/v1/events", handler.StreamEvents)
+	router.POST("/v1/webhooks", admin, handler.CreateWebhook)
+	router.GET("/v1/webhooks", admin, handler.ListWebhooks)
+	router.DELETE("/v1/webhooks/:id", admin, handler.DeleteWebhook)
+	router.GET("/v1/webhooks/:id/deliveries", admin, handler.ListWebhookDeliveries)
+	router.GET("/v1/screening/hits", admin, handler.ListScreeningHits)
+	router.GET("/v1/screening/hits/:id", admin, handler.GetScreeningHit)
+	router.POST("/v1/screening/hits/:id/decision", admin, handler.DecideScreeningHit)
+	router.GET("/v1/admin/quality", admin, handler.GetQualityReport)
+	router.POST("/v1/admin/quality/fix", admin, handler.FixQuality)
 	return router
 }
 
@@ -168,6 +205,95 @@ func TestGetCountryDetails_Success(t *testing.T) {
 	assert.Len(t, banks, 2, "Should have 2 banks for Poland")
 }
 
+func TestGetSwiftCodeDetails_NotModified(t *testing.T) {
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTDEPWXXX", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag, "Response should include an ETag")
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"), "Response should include Last-Modified")
+
+	// same ETag -> 304 without body
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/swift-codes/TESTDEPWXXX", nil)
+	req.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+
+	// different ETag -> full response
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/swift-codes/TESTDEPWXXX", nil)
+	req.Header.Set("If-None-Match", `"outdated"`)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetCountryDetails_IfModifiedSince(t *testing.T) {
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/country/DE", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/swift-codes/country/DE", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUpdateSwiftCode_IfMatch(t *testing.T) {
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTDEPWXXX", nil)
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+
+	update := model.Bank{
+		Address:       "New German Address",
+		Name:          "German Bank",
+		CountryCode:   "DE",
+		CountryName:   "Germany",
+		IsHeadquarter: true,
+	}
+	jsonValue, _ := json.Marshal(update)
+
+	// stale ETag is rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/v1/swift-codes/TESTDEPWXXX", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"outdated"`)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// current ETag is accepted
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/v1/swift-codes/TESTDEPWXXX", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"), "ETag should change after an update")
+
+	bank, err := database.GetBankBySwiftCode(testDB, "TESTDEPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "New German Address", bank.Address)
+}
+
 func TestGetCountryDetails_NotFound(t *testing.T) {
 	router := setupRouter()
 
@@ -204,6 +330,30 @@ func TestAddSwiftCode_Success(t *testing.T) {
 	assert.Equal(t, "UNITED STATES", bank.CountryName) // Should be uppercase
 }
 
+func TestAddSwiftCode_PostalAddress(t *testing.T) {
+	router := setupRouter()
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code = 'NEWUS888ABC'")
+
+	jsonValue, _ := json.Marshal(model.Bank{
+		Address:     "200 Park Avenue, New York, 10166",
+		Name:        "New Bank #3",
+		CountryCode: "US",
+		CountryName: "United States",
+		SwiftCode:   "NEWUS888ABC",
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the address is parsed into its parts and kept as sent
+	bank, err := database.GetBankBySwiftCode(testDB, "NEWUS888ABC")
+	assert.NoError(t, err)
+	assert.Equal(t, "200 Park Avenue, New York, 10166", bank.Address)
+	assert.Equal(t, &model.PostalAddress{StreetName: "Park Avenue", BuildingNumber: "200", PostCode: "10166", TownName: "New York"}, bank.PostalAddress)
+}
+
 func TestAddSwiftCode_InvalidJSON(t *testing.T) {
 	router := setupRouter()
 
@@ -217,6 +367,98 @@ func TestAddSwiftCode_InvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestBatchLookupSwiftCodes(t *testing.T) {
+	router := setupRouter()
+
+	body := []byte(`{"swiftCodes": ["TESTPLPW", "testdepwxxx", "NONEXIST", "BAD"]}`)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes/lookup", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		SwiftCodes []model.Bank `json:"swiftCodes"`
+		NotFound   []string     `json:"notFound"`
+		Invalid    []string     `json:"invalid"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	// BIC8 resolves to the headquarter, lookups are case insensitive
+	assert.Len(t, response.SwiftCodes, 2)
+	assert.Equal(t, "TESTPLPWXXX", response.SwiftCodes[0].SwiftCode)
+	assert.Equal(t, "TESTDEPWXXX", response.SwiftCodes[1].SwiftCode)
+	assert.Equal(t, []string{"NONEXIST"}, response.NotFound)
+	assert.Equal(t, []string{"BAD"}, response.Invalid)
+}
+
+func TestBatchLookupSwiftCodes_Empty(t *testing.T) {
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes/lookup", bytes.NewBuffer([]byte(`{"swiftCodes": []}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBatchLookupSwiftCodes_Quota(t *testing.T) {
+	router := gin.New()
+	policy := ratelimit.Policy{Name: "lookup", PerIP: ratelimit.Limit{DailyQuota: 3}}
+	router.POST("/v1/swift-codes/lookup", ratelimit.Metered(ratelimit.NewMemoryStore(), policy), handler.BatchLookupSwiftCodes)
+
+	lookup := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/swift-codes/lookup", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// duplicates and invalid codes are not charged
+	w := lookup(`{"swiftCodes": ["TESTPLPW", "TESTPLPW", "testdepwxxx", "BAD"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining"))
+
+	w = lookup(`{"swiftCodes": ["TESTPLPWXXX", "TESTDEPWXXX"]}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = lookup(`{"swiftCodes": ["TESTPLPWXXX"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBatchLookupSwiftCodes_UnknownAPIKey(t *testing.T) {
+	router := gin.New()
+	keys, _ := auth.ParseKeys("team-a=team-a-key-0123456789")
+	policy := ratelimit.Policy{Name: "lookup", PerIP: ratelimit.Limit{DailyQuota: 3}, Keys: keys}
+	router.POST("/v1/swift-codes/lookup", ratelimit.Metered(ratelimit.NewMemoryStore(), policy), handler.BatchLookupSwiftCodes)
+
+	lookup := func(apiKey, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/swift-codes/lookup", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(ratelimit.APIKeyHeader, apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// made up keys are charged against the quota of the IP, not a fresh one each
+	w := lookup("made-up-key-1", `{"swiftCodes": ["TESTPLPWXXX", "TESTDEPWXXX", "TESTPLPW123"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining"))
+
+	w = lookup("made-up-key-2", `{"swiftCodes": ["TESTPLPWXXX"]}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// an issued key has its own (here unlimited) quota
+	w = lookup("team-a-key-0123456789", `{"swiftCodes": ["TESTPLPWXXX"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestDeleteSwiftCode_Success(t *testing.T) {
 	router := setupRouter()
 
@@ -244,3 +486,586 @@ func TestDeleteSwiftCode_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestReadyz(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// not ready while the initial import is running
+	handler.SetImporting(true)
+	defer handler.SetImporting(false)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// liveness does not depend on the import
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/healthz", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestWebhooks(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", adminAuth)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		return request("POST", "/v1/webhooks", body)
+	}
+
+	// only admins manage subscriptions
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/webhooks", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"url": "ftp://93.184.215.14/hook"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"url": "https://93.184.215.14/hook", "eventTypes": ["renamed"]}`).Code)
+
+	// internal targets are refused
+	for _, url := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hook", "http://[::1]/hook"} {
+		w := post(`{"url": "` + url + `"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.Contains(t, w.Body.String(), "not a public address", url)
+	}
+
+	// without a secret one is generated and returned once
+	w = post(`{"url": "https://93.184.215.14/hook", "eventTypes": ["created"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created model.Webhook
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, []string{"created"}, created.EventTypes)
+	id := strconv.FormatInt(created.ID, 10)
+
+	w = request("GET", "/v1/webhooks", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"url":"https://93.184.215.14/hook"`)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	w = request("GET", "/v1/webhooks/"+id+"/deliveries", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request("DELETE", "/v1/webhooks/"+id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request("DELETE", "/v1/webhooks/"+id, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStreamEvents(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	last, err := database.LatestEventIDContext(context.Background(), testDB)
+	assert.NoError(t, err)
+
+	for _, bank := range []model.Bank{
+		{Address: "Event Street 1", Name: "Event Bank", CountryCode: "SE", CountryName: "SWEDEN", IsHeadquarter: true, SwiftCode: "EVNTSESSXXX"},
+		{Address: "Event Street 2", Name: "Other Bank", CountryCode: "NO", CountryName: "NORWAY", IsHeadquarter: true, SwiftCode: "EVNTNONOXXX"},
+	} {
+		assert.NoError(t, database.CreateBankContext(context.Background(), testDB, bank))
+	}
+	_, err = database.DeleteBankContext(context.Background(), testDB, "EVNTSESSXXX", time.Time{})
+	assert.NoError(t, err)
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'EVNT%'")
+
+	// the handler streams until the client goes away
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/v1/events?country=se", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(last, 10))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event:created\n")
+	assert.Contains(t, body, "event:deleted\n")
+	assert.Contains(t, body, `"swiftCode":"EVNTSESSXXX"`)
+	assert.NotContains(t, body, "EVNTNONOXXX")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/events?swiftCode=EVNT", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// a client may only keep a limited number of streams open, made up API keys do not
+	// make it another client
+	handler.SetMaxEventStreams(1)
+	defer handler.SetMaxEventStreams(5)
+	attempt := 0
+
+	open, closeOpen := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req, _ := http.NewRequestWithContext(open, "GET", "/v1/events", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	assert.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/v1/events", nil)
+		attempt++
+		req.Header.Set(ratelimit.APIKeyHeader, "made-up-key-"+strconv.Itoa(attempt))
+		router.ServeHTTP(w, req)
+		return w.Code == http.StatusTooManyRequests
+	}, 2*time.Second, 10*time.Millisecond)
+	closeOpen()
+	<-done
+}
+
+func TestValidateIBAN(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	registry, err := iban.LoadRegistry("../../data/iban_registry.csv")
+	assert.NoError(t, err)
+	_, err = registry.LoadBankCodes("../../data/iban_bank_codes.csv")
+	assert.NoError(t, err)
+	handler.SetIBANRegistry(registry)
+	defer handler.SetIBANRegistry(nil)
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "1 Knyaz Alexander I Sq.", Name: "Bulgarian National Bank", CountryCode: "BG",
+		CountryName: "BULGARIA", IsHeadquarter: true, SwiftCode: "BNBGBGSFXXX",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code = 'BNBGBGSFXXX'")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/iban/BG80%20BNBG%209661%201020%203456%2078/validate", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, true, resp["valid"])
+	assert.Equal(t, "BG80BNBG96611020345678", resp["iban"])
+	assert.Equal(t, "BNBG", resp["bankCode"])
+	if bank, ok := resp["bank"].(map[string]any); assert.True(t, ok) {
+		assert.Equal(t, "BNBGBGSFXXX", bank["swiftCode"])
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/iban/BG81BNBG96611020345678/validate", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"valid":false`)
+	assert.Contains(t, w.Body.String(), "check digits")
+}
+
+func TestGetBankByIdentifier(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	_, _, err := database.ImportIdentifiersContext(context.Background(), testDB, []model.BankIdentifier{
+		{Scheme: model.SchemeBLZ, Identifier: "37040044", SwiftCode: "TESTDEPWXXX"},
+	})
+	assert.NoError(t, err)
+	defer testDB.Exec("DELETE FROM bank_identifiers")
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"linked", "/v1/identifiers/blz/370%20400%2044", http.StatusOK},
+		{"not linked", "/v1/identifiers/blz/10000000", http.StatusNotFound},
+		{"malformed", "/v1/identifiers/blz/1234", http.StatusBadRequest},
+		{"unknown scheme", "/v1/identifiers/routing/37040044", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Code, w.Body.String())
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/identifiers/blz/37040044", nil)
+	router.ServeHTTP(w, req)
+
+	var resp struct {
+		Scheme     string     `json:"scheme"`
+		Identifier string     `json:"identifier"`
+		Bank       model.Bank `json:"bank"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "blz", resp.Scheme)
+	assert.Equal(t, "37040044", resp.Identifier)
+	assert.Equal(t, "TESTDEPWXXX", resp.Bank.SwiftCode)
+}
+
+func TestNearbySwiftCodes(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	gazetteer, err := geo.LoadGazetteer("../../data/gazetteer.csv")
+	assert.NoError(t, err)
+	database.SetGazetteer(gazetteer)
+	handler.SetGazetteer(gazetteer)
+	defer database.SetGazetteer(nil)
+	defer handler.SetGazetteer(nil)
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "Sopot Address", Name: "Sopot Bank", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "NEARPLPGXXX", TownName: "SOPOT",
+	})
+	database.InsertBank(testDB, model.Bank{
+		Address: "Gdansk Address", Name: "Gdansk Bank", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "NEARPLPDXXX", TownName: "GDANSK",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'NEAR%'")
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	// Gdansk is about 11 km from the centre of Sopot
+	w, resp := get("/v1/swift-codes/nearby?town=sopot&radiusKm=20")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	if codes, ok := resp["swiftCodes"].([]any); assert.True(t, ok) && assert.Len(t, codes, 2) {
+		first := codes[0].(map[string]any)
+		assert.Equal(t, "NEARPLPGXXX", first["swiftCode"], "Closest bank should come first")
+		assert.Equal(t, "SOPOT", first["townName"])
+		assert.Contains(t, first, "location")
+		assert.InDelta(t, 11, codes[1].(map[string]any)["distanceKm"], 2)
+	}
+
+	w, resp = get("/v1/swift-codes/nearby?lat=54.4418&lon=18.5601&radiusKm=5")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, resp["swiftCodes"], 1)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"unknown town", "/v1/swift-codes/nearby?town=ATLANTIS", http.StatusNotFound},
+		{"town in another country", "/v1/swift-codes/nearby?town=SOPOT&country=DE", http.StatusNotFound},
+		{"no center", "/v1/swift-codes/nearby", http.StatusBadRequest},
+		{"latitude out of range", "/v1/swift-codes/nearby?lat=91&lon=0", http.StatusBadRequest},
+		{"town and coordinates", "/v1/swift-codes/nearby?town=SOPOT&lat=54&lon=18", http.StatusBadRequest},
+		{"radius too large", "/v1/swift-codes/nearby?lat=54&lon=18&radiusKm=5000", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := get(tt.path)
+			assert.Equal(t, tt.expected, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestGetSwiftCodeClock(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	handler.SetBusinessHours(&calendar.Hours{Open: calendar.TimeOfDay{Hour: 9}, Close: calendar.TimeOfDay{Hour: 17}, Cutoff: calendar.TimeOfDay{Hour: 16}})
+	defer handler.SetBusinessHours(nil)
+
+	holidays, err := parser.ParseHolidayFile("../../data/holidays.csv", "PL")
+	assert.NoError(t, err)
+	assert.NoError(t, database.ImportHolidaysContext(context.Background(), testDB, holidays))
+	defer testDB.Exec("DELETE FROM holidays")
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "Clock Address", Name: "Clock Bank", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "CLOCPLPWXXX", TimeZone: "Europe/Warsaw",
+	})
+	database.InsertBank(testDB, model.Bank{
+		Address: "Clock Address", Name: "Clock Bank", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "CLOCPLPKXXX",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'CLOC%'")
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	// after the cutoff on the day before Labour Day, the next cutoff is on Monday
+	w, resp := get("/v1/swift-codes/CLOCPLPWXXX/clock?at=2026-04-30T14:30:00Z")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Europe/Warsaw", resp["timeZone"])
+	assert.Equal(t, "2026-04-30T16:30:00+02:00", resp["localTime"])
+	assert.Equal(t, true, resp["businessDay"])
+	assert.Equal(t, true, resp["open"])
+	assert.Equal(t, "2026-05-04T14:00:00Z", resp["nextCutoff"])
+	assert.NotContains(t, resp, "holiday")
+
+	w, resp = get("/v1/swift-codes/CLOCPLPWXXX/clock?at=2026-05-01T08:00:00Z")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, resp["open"])
+	assert.Equal(t, "Labour Day", resp["holiday"])
+
+	w, resp = get("/v1/swift-codes/CLOCPLPWXXX")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Europe/Warsaw", resp["timeZone"])
+
+	body := `{"address":"A","bankName":"B","countryISO2":"PL","countryName":"POLAND","isHeadquarter":true,"swiftCode":"CLOCPLPZXXX","timeZone":"Mars/Olympus"}`
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Unknown time zones are rejected")
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"unknown code", "/v1/swift-codes/NONEPLPWXXX/clock", http.StatusNotFound},
+		{"no time zone", "/v1/swift-codes/CLOCPLPKXXX/clock", http.StatusUnprocessableEntity},
+		{"invalid instant", "/v1/swift-codes/CLOCPLPWXXX/clock?at=tomorrow", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := get(tt.path)
+			assert.Equal(t, tt.expected, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestHolidaysAndBusinessDays(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	err := database.ImportHolidaysContext(context.Background(), testDB, []model.Holiday{
+		{CountryCode: "PL", Date: "2026-05-01", Name: "Labour Day"},
+		{CountryCode: "PL", Date: "2026-05-03", Name: "Constitution Day"},
+		{CountryCode: "PL", Date: "2027-01-01", Name: "New Year's Day"},
+		{CountryCode: "DE", Date: "2026-05-01", Name: "Tag der Arbeit"},
+	})
+	assert.NoError(t, err)
+	defer testDB.Exec("DELETE FROM holidays")
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "Holiday Address", Name: "Holiday Bank", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "HOLIPLPWXXX",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code LIKE 'HOLI%'")
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := get("/v1/holidays/pl?from=2026-01-01&to=2026-12-31")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "PL", resp["countryISO2"])
+	if holidays, ok := resp["holidays"].([]any); assert.True(t, ok) && assert.Len(t, holidays, 2) {
+		assert.Equal(t, "2026-05-01", holidays[0].(map[string]any)["date"])
+		assert.Equal(t, "Constitution Day", holidays[1].(map[string]any)["name"])
+	}
+
+	w, resp = get("/v1/holidays/PL?from=2027-01-01")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2027-12-31", resp["to"])
+	assert.Len(t, resp["holidays"], 1)
+
+	// Labour Day is a Friday, the next business day is Monday
+	w, resp = get("/v1/business-days/PL/2026-05-01")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, false, resp["businessDay"])
+	assert.Equal(t, false, resp["weekend"])
+	assert.Equal(t, "Labour Day", resp["holiday"])
+	assert.Equal(t, "2026-05-04", resp["nextBusinessDay"])
+	assert.Equal(t, "2026-04-30", resp["previousBusinessDay"])
+
+	w, resp = get("/v1/swift-codes/HOLIPLPWXXX/business-days/2026-05-04")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "HOLIPLPWXXX", resp["swiftCode"])
+	assert.Equal(t, true, resp["businessDay"])
+	assert.Equal(t, "2026-05-05", resp["nextBusinessDay"])
+	assert.Equal(t, "2026-04-30", resp["previousBusinessDay"])
+
+	// countries without holidays only close on weekends
+	_, resp = get("/v1/business-days/FR/2026-05-01")
+	assert.Equal(t, true, resp["businessDay"])
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"invalid country", "/v1/holidays/POL", http.StatusBadRequest},
+		{"invalid from", "/v1/holidays/PL?from=May", http.StatusBadRequest},
+		{"reversed range", "/v1/holidays/PL?from=2026-12-31&to=2026-01-01", http.StatusBadRequest},
+		{"range too long", "/v1/holidays/PL?from=2000-01-01&to=2026-01-01", http.StatusBadRequest},
+		{"invalid date", "/v1/business-days/PL/2026-02-30", http.StatusBadRequest},
+		{"unknown SWIFT code", "/v1/swift-codes/NONEPLPWXXX/business-days/2026-05-04", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := get(tt.path)
+			assert.Equal(t, tt.expected, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestScreeningHits(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+	ctx := context.Background()
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "Hamburg", Name: "BANK MELLI IRAN", CountryCode: "DE", CountryName: "GERMANY",
+		IsHeadquarter: true, SwiftCode: "MELIDEHHXXX",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code = 'MELIDEHHXXX'")
+	defer testDB.Exec("DELETE FROM screening_decisions WHERE swift_code = 'MELIDEHHXXX'")
+
+	entries := []model.SanctionEntry{{List: "handler-test", EntryID: "1", Name: "Bank Melli Iran", Countries: []string{"DE"}}}
+	_, err := database.ImportSanctionListContext(ctx, testDB, "handler-test", entries)
+	assert.NoError(t, err)
+	defer testDB.Exec("DELETE FROM sanction_entries WHERE list = 'handler-test'")
+	_, err = database.ScreenBanksContext(ctx, testDB, screening.NewMatcher(entries, 0), true)
+	assert.NoError(t, err)
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", adminAuth)
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	_, resp := do("GET", "/v1/swift-codes/MELIDEHHXXX", "")
+	assert.Equal(t, model.ScreeningPotentialMatch, resp["screeningStatus"])
+
+	w, resp := do("GET", "/v1/screening/hits?status=open&swiftCode=MELIDEHHXXX", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	hits, ok := resp["hits"].([]any)
+	if !assert.True(t, ok) || !assert.Len(t, hits, 1) {
+		return
+	}
+	hit := hits[0].(map[string]any)
+	assert.Equal(t, "handler-test", hit["list"])
+	id := strconv.FormatInt(int64(hit["id"].(float64)), 10)
+
+	w, _ = do("GET", "/v1/screening/hits?status=closed", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// decisions need an admin key
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/screening/hits/"+id+"/decision", bytes.NewBufferString(`{"decision": "accept"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, _ = do("POST", "/v1/screening/hits/"+id+"/decision", `{"decision": "maybe"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the reviewer is the holder of the key, not a name in the body
+	w, resp = do("POST", "/v1/screening/hits/"+id+"/decision", `{"decision": "dismiss", "reviewer": "someone else", "comment": "different institution"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, model.HitDismissed, resp["status"])
+
+	w, resp = do("GET", "/v1/screening/hits/"+id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	if decisions, ok := resp["decisions"].([]any); assert.True(t, ok) && assert.Len(t, decisions, 1) {
+		decision := decisions[0].(map[string]any)
+		assert.Equal(t, "different institution", decision["comment"])
+		assert.Equal(t, "test-admin", decision["reviewer"])
+	}
+
+	_, resp = do("GET", "/v1/swift-codes/MELIDEHHXXX", "")
+	assert.Equal(t, model.ScreeningClear, resp["screeningStatus"])
+
+	w, _ = do("GET", "/v1/screening/hits/999999999", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = do("POST", "/v1/screening/hits/abc/decision", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestQualityReport(t *testing.T) {
+	router := setupRouter()
+	config.SetDB(testDB)
+
+	database.InsertBank(testDB, model.Bank{
+		Address: "ULICA  1 ", Name: " QUALITY TEST BANK", CountryCode: "PL", CountryName: "POLAND",
+		IsHeadquarter: true, SwiftCode: "QUALPLPWXXX",
+	})
+	defer testDB.Exec("DELETE FROM banks WHERE swift_code = 'QUALPLPWXXX'")
+
+	do := func(method, path string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", adminAuth)
+		router.ServeHTTP(w, req)
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	// the admin routes need an admin key
+	for _, route := range []struct{ method, path string }{{"GET", "/v1/admin/quality"}, {"POST", "/v1/admin/quality/fix"}} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(route.method, route.path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, route.path)
+	}
+
+	w, _ := do("GET", "/v1/admin/quality?checks=typos")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = do("GET", "/v1/admin/quality?samples=-1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, resp := do("GET", "/v1/admin/quality?checks=whitespace&samples=100")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	checks, ok := resp["checks"].([]any)
+	if !assert.True(t, ok) || !assert.Len(t, checks, 1) {
+		return
+	}
+	result := checks[0].(map[string]any)
+	assert.Equal(t, "whitespace", result["check"])
+	assert.Equal(t, true, result["fixable"])
+	assert.Contains(t, w.Body.String(), "QUALPLPWXXX")
+
+	w, resp = do("POST", "/v1/admin/quality/fix?checks=whitespace")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.GreaterOrEqual(t, resp["fixed"], float64(1))
+
+	bank, err := database.GetBankBySwiftCode(testDB, "QUALPLPWXXX")
+	assert.NoError(t, err)
+	assert.Equal(t, "QUALITY TEST BANK", bank.Name)
+	assert.Equal(t, "ULICA 1", bank.Address)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+)
+
+// set while the initial CSV import is running
+var importing atomic.Bool
+
+func SetImporting(running bool) {
+	importing.Store(running)
+}
+
+// liveness: the process is up and serving requests
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readiness: the database is reachable and the initial import has finished
+func Readyz(c *gin.Context) {
+	checks := gin.H{"database": "ok", "import": "done"}
+	ready := true
+
+	if importing.Load() {
+		checks["import"] = "running"
+		ready = false
+	}
+
+	db := config.GetDB()
+	if db == nil {
+		checks["database"] = "not connected"
+		ready = false
+	} else {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			checks["database"] = "unreachable"
+			ready = false
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
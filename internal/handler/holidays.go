@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/calendar"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// longest range of a holiday list
+const maxHolidayRangeDays = 3660
+
+type holidayList struct {
+	CountryCode string          `json:"countryISO2"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Holidays    []model.Holiday `json:"holidays"`
+}
+
+type businessDayResponse struct {
+	SwiftCode           string `json:"swiftCode,omitempty"`
+	CountryCode         string `json:"countryISO2"`
+	Date                string `json:"date"`
+	BusinessDay         bool   `json:"businessDay"`
+	Weekend             bool   `json:"weekend"`
+	Holiday             string `json:"holiday,omitempty"`
+	NextBusinessDay     string `json:"nextBusinessDay"`
+	PreviousBusinessDay string `json:"previousBusinessDay"`
+}
+
+// holidays of a country between from and to (YYYY-MM-DD, both included),
+// the current year by default
+func ListHolidays(c *gin.Context) {
+	countryCode := strings.ToUpper(c.Param("countryISO2code"))
+	if len(countryCode) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Country code must have 2 letters"})
+		return
+	}
+
+	from, to, ok := dateRange(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	holidays, err := database.ListHolidaysContext(ctx, config.GetDB(), countryCode, from, to)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when listing holidays", "country", countryCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+	if holidays == nil {
+		holidays = []model.Holiday{}
+	}
+
+	c.JSON(http.StatusOK, holidayList{
+		CountryCode: countryCode,
+		From:        from.Format(model.DateLayout),
+		To:          to.Format(model.DateLayout),
+		Holidays:    holidays,
+	})
+}
+
+// reads ?from= and ?to=, a missing end is one year from the other one.
+// Writes the error response and returns false when the range is invalid.
+func dateRange(c *gin.Context) (from, to time.Time, ok bool) {
+	fromParam, hasFrom := c.GetQuery("from")
+	toParam, hasTo := c.GetQuery("to")
+
+	var err error
+	if hasFrom {
+		if from, err = time.Parse(model.DateLayout, fromParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "from must be a date (YYYY-MM-DD)"})
+			return from, to, false
+		}
+	}
+	if hasTo {
+		if to, err = time.Parse(model.DateLayout, toParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "to must be a date (YYYY-MM-DD)"})
+			return from, to, false
+		}
+	}
+
+	switch {
+	case !hasFrom && !hasTo:
+		from = time.Date(time.Now().UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(1, 0, -1)
+	case !hasTo:
+		to = from.AddDate(1, 0, -1)
+	case !hasFrom:
+		from = to.AddDate(-1, 0, 1)
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "to must not be before from"})
+		return from, to, false
+	}
+	if to.Sub(from) > maxHolidayRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "The range must not be longer than 10 years"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// whether a date is a business day in a country, with the business days around it
+func GetBusinessDay(c *gin.Context) {
+	countryCode := strings.ToUpper(c.Param("countryISO2code"))
+	if len(countryCode) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Country code must have 2 letters"})
+		return
+	}
+	writeBusinessDay(c, "", countryCode)
+}
+
+// like GetBusinessDay for the country of a SWIFT code
+func GetSwiftCodeBusinessDay(c *gin.Context) {
+	ctx := c.Request.Context()
+	swiftCode := strings.ToUpper(c.Param("swiftCode"))
+	bank, err := database.GetBankBySwiftCodeContext(ctx, config.GetDB(), swiftCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "SWIFT code not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when fetching a bank", "swift_code", swiftCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+	writeBusinessDay(c, bank.SwiftCode, bank.CountryCode)
+}
+
+func writeBusinessDay(c *gin.Context, swiftCode, countryCode string) {
+	date, err := time.Parse(model.DateLayout, c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "date must be YYYY-MM-DD"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cal, err := holidayCalendar(ctx, countryCode, date)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when loading holidays", "country", countryCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+
+	next, errNext := cal.NextBusinessDay(countryCode, date)
+	previous, errPrevious := cal.PreviousBusinessDay(countryCode, date)
+	if errNext != nil || errPrevious != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "No business day within a year"})
+		return
+	}
+
+	res := businessDayResponse{
+		SwiftCode:           swiftCode,
+		CountryCode:         countryCode,
+		Date:                date.Format(model.DateLayout),
+		BusinessDay:         cal.IsBusinessDay(countryCode, date),
+		Weekend:             calendar.IsWeekend(date),
+		NextBusinessDay:     next.Format(model.DateLayout),
+		PreviousBusinessDay: previous.Format(model.DateLayout),
+	}
+	res.Holiday, _ = cal.Holidays.Holiday(countryCode, date)
+	c.JSON(http.StatusOK, res)
+}
+
+// a calendar with the holidays of a country a year (the longest business day
+// search) and a few days of time zone slack around day
+func holidayCalendar(ctx context.Context, countryCode string, day time.Time) (calendar.Calendar, error) {
+	from, to := day.AddDate(-1, 0, -2), day.AddDate(1, 0, 2)
+	holidays, err := database.ListHolidaysContext(ctx, config.GetDB(), countryCode, from, to)
+	if err != nil {
+		return calendar.Calendar{}, err
+	}
+	return calendar.Calendar{Holidays: calendar.NewHolidays(holidays...)}, nil
+}
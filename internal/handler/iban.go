@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/iban"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// IBAN formats and bank codes, nil when IBAN validation is not configured
+var ibanRegistry *iban.Registry
+
+func SetIBANRegistry(r *iban.Registry) {
+	ibanRegistry = r
+}
+
+type ibanResponse struct {
+	IBAN        string      `json:"iban"`
+	Valid       bool        `json:"valid"`
+	Message     string      `json:"message,omitempty"`
+	CountryCode string      `json:"countryISO2,omitempty"`
+	CheckDigits string      `json:"checkDigits,omitempty"`
+	BBAN        string      `json:"bban,omitempty"`
+	BankCode    string      `json:"bankCode,omitempty"`
+	SwiftCode   string      `json:"swiftCode,omitempty"`
+	Bank        *model.Bank `json:"bank,omitempty"`
+}
+
+// checks an IBAN offline and, when its bank code is in the bank code table,
+// returns the matching directory entry. Invalid IBANs are a 200 with valid=false.
+func ValidateIBAN(c *gin.Context) {
+	if ibanRegistry == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "IBAN validation is not configured"})
+		return
+	}
+
+	res := ibanRegistry.Validate(c.Param("iban"))
+	resp := ibanResponse{IBAN: res.IBAN, Valid: res.Valid()}
+	if !res.Valid() {
+		resp.Message = res.Err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	resp.CountryCode = res.CountryCode
+	resp.CheckDigits = res.CheckDigits
+	resp.BBAN = res.BBAN
+	resp.BankCode = res.BankCode
+	resp.SwiftCode = res.BIC
+
+	if res.BIC != "" {
+		ctx := c.Request.Context()
+		bank, err := database.GetBankBySwiftCodeContext(ctx, config.GetDB(), res.BIC)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// listed in the bank code table but not in the directory
+		case err != nil:
+			logging.FromContext(ctx).Error("Error when looking up the bank of an IBAN", "swift_code", res.BIC, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to look up bank"})
+			return
+		default:
+			resp.Bank = bank
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+type identifierResponse struct {
+	Scheme     string      `json:"scheme"`
+	Identifier string      `json:"identifier"`
+	Bank       *model.Bank `json:"bank"`
+}
+
+// returns the bank linked to a national identifier, e.g. /v1/identifiers/sort-code/20-00-00
+func GetBankByIdentifier(c *gin.Context) {
+	scheme := strings.ToLower(c.Param("scheme"))
+	id, err := model.NormalizeIdentifier(scheme, c.Param("id"))
+	switch {
+	case errors.Is(err, model.ErrUnknownScheme):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown scheme, expected one of " + strings.Join(model.IdentifierSchemes, ", ")})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid " + scheme + " identifier"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	bank, err := database.GetBankByIdentifierContext(ctx, config.GetDB(), scheme, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Identifier not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when looking up an identifier", "scheme", scheme, "identifier", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to look up identifier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identifierResponse{Scheme: scheme, Identifier: id, Bank: bank})
+}
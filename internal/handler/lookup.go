@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/ratelimit"
+)
+
+const maxBatchLookup = 5000
+
+type batchLookupRequest struct {
+	SwiftCodes []string `json:"swiftCodes"`
+}
+
+// resolves many BIC8/BIC11 codes in one call, every distinct valid code counts
+// against the lookup quota like a single code lookup
+func BatchLookupSwiftCodes(c *gin.Context) {
+	var req batchLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+	if len(req.SwiftCodes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "swiftCodes must not be empty"})
+		return
+	}
+	if len(req.SwiftCodes) > maxBatchLookup {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Too many SWIFT codes in one request"})
+		return
+	}
+
+	// requested code -> normalized BIC11
+	requested := make([]string, 0, len(req.SwiftCodes))
+	normalized := make(map[string]string, len(req.SwiftCodes))
+	invalid := []string{}
+	var query []string
+
+	for _, code := range req.SwiftCodes {
+		if _, seen := normalized[code]; seen {
+			continue
+		}
+		bic11, ok := normalizeBIC(code)
+		if !ok {
+			invalid = append(invalid, code)
+			continue
+		}
+		normalized[code] = bic11
+		requested = append(requested, code)
+		query = append(query, bic11)
+	}
+
+	if !ratelimit.Charge(c, len(query)) {
+		return
+	}
+
+	var banks []model.Bank
+	if len(query) > 0 {
+		var err error
+		banks, err = database.GetBanksBySwiftCodesContext(c.Request.Context(), config.GetDB(), query)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("DB error", "swift_codes", len(query), "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to look up SWIFT codes"})
+			return
+		}
+	}
+
+	byCode := make(map[string]model.Bank, len(banks))
+	for _, b := range banks {
+		byCode[b.SwiftCode] = b
+	}
+
+	// keep the order of the request
+	found := []model.Bank{}
+	notFound := []string{}
+	added := make(map[string]bool, len(banks))
+	for _, code := range requested {
+		b, ok := byCode[normalized[code]]
+		if !ok {
+			notFound = append(notFound, code)
+			continue
+		}
+		if !added[b.SwiftCode] {
+			found = append(found, b)
+			added[b.SwiftCode] = true
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"swiftCodes": found,
+		"notFound":   notFound,
+		"invalid":    invalid,
+	})
+}
+
+// BIC8 codes refer to the headquarter (BIC8 + "XXX")
+func normalizeBIC(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch len(code) {
+	case 8:
+		return code + "XXX", true
+	case 11:
+		return code, true
+	default:
+		return "", false
+	}
+}
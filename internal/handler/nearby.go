@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/geo"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+const (
+	defaultNearbyRadiusKm = 10
+	maxNearbyRadiusKm     = 500
+	defaultNearbyLimit    = 50
+	maxNearbyLimit        = 500
+)
+
+// town coordinates for ?town= searches, nil when no gazetteer is configured
+var townGazetteer *geo.Gazetteer
+
+func SetGazetteer(g *geo.Gazetteer) {
+	townGazetteer = g
+}
+
+type nearbyResponse struct {
+	Center     model.Location     `json:"center"`
+	RadiusKm   float64            `json:"radiusKm"`
+	SwiftCodes []model.NearbyBank `json:"swiftCodes"`
+}
+
+// banks closest to a coordinate (lat, lon) or to the centre of a town (town, optionally
+// country), within radiusKm. country also restricts the results to that country.
+func NearbySwiftCodes(c *gin.Context) {
+	country := strings.ToUpper(c.Query("country"))
+	if country != "" && len(country) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "country must have 2 letters"})
+		return
+	}
+
+	radiusKm, ok := floatQuery(c, "radiusKm", defaultNearbyRadiusKm)
+	if !ok || radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "radiusKm must be a number above 0 and at most " + strconv.Itoa(maxNearbyRadiusKm)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultNearbyLimit)))
+	if err != nil || limit < 1 || limit > maxNearbyLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "limit must be between 1 and " + strconv.Itoa(maxNearbyLimit)})
+		return
+	}
+
+	var center model.Location
+	town := c.Query("town")
+	_, hasLat := c.GetQuery("lat")
+	_, hasLon := c.GetQuery("lon")
+	switch {
+	case town != "" && (hasLat || hasLon):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Use either town or lat and lon"})
+		return
+	case town != "":
+		if townGazetteer == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Town search is not configured"})
+			return
+		}
+		var towns []geo.Town
+		for _, t := range townGazetteer.Find(town) {
+			if country == "" || t.CountryCode == country {
+				towns = append(towns, t)
+			}
+		}
+		switch len(towns) {
+		case 0:
+			c.JSON(http.StatusNotFound, gin.H{"message": "Unknown town"})
+			return
+		case 1:
+			center = towns[0].Location
+		default:
+			codes := make([]string, len(towns))
+			for i, t := range towns {
+				codes[i] = t.CountryCode
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Town exists in " + strings.Join(codes, ", ") + ", add country"})
+			return
+		}
+	default:
+		lat, okLat := floatQuery(c, "lat", math.NaN())
+		lon, okLon := floatQuery(c, "lon", math.NaN())
+		if !okLat || !okLon || !(lat >= -90 && lat <= 90) || !(lon >= -180 && lon <= 180) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "lat (-90 to 90) and lon (-180 to 180) or town are required"})
+			return
+		}
+		center = model.Location{Latitude: lat, Longitude: lon}
+	}
+
+	ctx := c.Request.Context()
+	banks, err := database.NearbyBanksContext(ctx, config.GetDB(), center, radiusKm, country, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when searching nearby banks", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+	if banks == nil {
+		banks = []model.NearbyBank{}
+	}
+	for i := range banks {
+		banks[i].DistanceKm = math.Round(banks[i].DistanceKm*1000) / 1000
+	}
+
+	c.JSON(http.StatusOK, nearbyResponse{Center: center, RadiusKm: radiusKm, SwiftCodes: banks})
+}
+
+// a float query parameter, def when it is missing; false when it is not a finite number
+func floatQuery(c *gin.Context, name string, def float64) (float64, bool) {
+	s, ok := c.GetQuery(name)
+	if !ok {
+		return def, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
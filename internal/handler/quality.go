@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/quality"
+)
+
+const maxQualitySamples = 100
+
+// runs the data quality checks (all or ?checks=a,b) over the directory
+func GetQualityReport(c *gin.Context) {
+	banks, checks, samples, ok := qualityRun(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, quality.Run(banks, checks, samples))
+}
+
+// like GetQualityReport, then stores the corrections of the fixable checks. The report
+// lists the violations found before fixing.
+func FixQuality(c *gin.Context) {
+	banks, checks, samples, ok := qualityRun(c)
+	if !ok {
+		return
+	}
+	report := quality.Run(banks, checks, samples)
+
+	ctx := c.Request.Context()
+	fixed, err := database.FixBanksContext(ctx, config.GetDB(), quality.Fixes(banks, checks))
+	directoryCache.InvalidateBanks(fixed)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when fixing banks", "fixed", len(fixed), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to store the fixes, " + strconv.Itoa(len(fixed)) + " banks were fixed"})
+		return
+	}
+
+	logging.FromContext(ctx).Info("Quality fixes stored", "fixed", len(fixed))
+	report.Fixed = len(fixed)
+	c.JSON(http.StatusOK, report)
+}
+
+// reads ?checks= and ?samples= and loads the directory. Writes the error response
+// and returns false when the request is invalid or the banks cannot be read.
+func qualityRun(c *gin.Context) (banks []model.Bank, checks []quality.Check, samples int, ok bool) {
+	var names []string
+	if param := c.Query("checks"); param != "" {
+		names = strings.Split(param, ",")
+	}
+	checks, err := quality.LookupChecks(names...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, nil, 0, false
+	}
+
+	samples, err = strconv.Atoi(c.DefaultQuery("samples", strconv.Itoa(quality.DefaultSamples)))
+	if err != nil || samples < 0 || samples > maxQualitySamples {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "samples must be between 0 and " + strconv.Itoa(maxQualitySamples)})
+		return nil, nil, 0, false
+	}
+
+	ctx := c.Request.Context()
+	banks, err = database.AllBanksContext(ctx, config.GetDB())
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when loading banks", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return nil, nil, 0, false
+	}
+	return banks, checks, samples, true
+}
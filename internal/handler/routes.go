@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/graphql"
+	"github.com/white67/swift_api/internal/metrics"
+	"github.com/white67/swift_api/internal/openapi"
+)
+
+// RouteOptions holds the middleware put in front of the API routes, nil entries are skipped
+type RouteOptions struct {
+	LookupLimit  gin.HandlerFunc // single code lookups
+	LookupMeter  gin.HandlerFunc // charges batch lookups and GraphQL per bank, see ratelimit.Metered
+	DefaultLimit gin.HandlerFunc // every other /v1/swift-codes route
+	ExportLimit  gin.HandlerFunc // full exports
+	Admin        gin.HandlerFunc // admin key check of the admin routes, nil rejects every request to them
+	Validate     gin.HandlerFunc // request validation against the OpenAPI spec
+	SwaggerUI    bool            // serve /docs
+}
+
+// RegisterRoutes adds every route of the service, all of them are described in the OpenAPI spec
+func RegisterRoutes(router gin.IRouter, opts RouteOptions) error {
+	spec, err := openapi.Handler()
+	if err != nil {
+		return err
+	}
+	gql, err := graphql.Handler(graphql.DBStore())
+	if err != nil {
+		return err
+	}
+
+	lookup := chain(opts.LookupLimit, opts.Validate)
+	def := chain(opts.DefaultLimit, opts.Validate)
+	export := chain(opts.ExportLimit, opts.Validate)
+	metered := chain(opts.DefaultLimit, opts.LookupMeter, opts.Validate) // the handler charges the lookup quota per bank
+	adminAuth := opts.Admin
+	if adminAuth == nil {
+		adminAuth = auth.Middleware(nil)
+	}
+	admin := chain(opts.DefaultLimit, adminAuth, opts.Validate)
+
+	router.GET("/v1/swift-codes/export", append(export, ExportSwiftCodes)...)
+	router.GET("/v1/swift-codes/nearby", append(def, NearbySwiftCodes)...)
+	router.GET("/v1/swift-codes/:swiftCode", append(lookup, GetSwiftCodeDetails)...)
+	router.GET("/v1/swift-codes/:swiftCode/clock", append(lookup, GetSwiftCodeClock)...)
+	router.GET("/v1/swift-codes/:swiftCode/business-days/:date", append(lookup, GetSwiftCodeBusinessDay)...)
+	router.GET("/v1/swift-codes/country/:countryISO2code", append(def, GetCountryDetails)...)
+	router.POST("/v1/swift-codes", append(def, AddSwiftCode)...)
+	router.POST("/v1/swift-codes/lookup", append(metered, BatchLookupSwiftCodes)...)
+	router.PUT("/v1/swift-codes/:swiftCode", append(def, UpdateSwiftCode)...)
+	router.DELETE("/v1/swift-codes/:swiftCode", append(def, DeleteSwiftCode)...)
+
+	router.GET("/v1/iban/:iban/validate", append(lookup, ValidateIBAN)...)
+	router.GET("/v1/identifiers/:scheme/:id", append(lookup, GetBankByIdentifier)...)
+
+	router.GET("/v1/holidays/:countryISO2code", append(def, ListHolidays)...)
+	router.GET("/v1/business-days/:countryISO2code/:date", append(def, GetBusinessDay)...)
+
+	router.POST("/graphql", append(metered, gql)...)
+
+	router.GET("/v1/events", append(def, StreamEvents)...)
+
+	router.POST("/v1/webhooks", append(admin, CreateWebhook)...)
+	router.GET("/v1/webhooks", append(admin, ListWebhooks)...)
+	router.DELETE("/v1/webhooks/:id", append(admin, DeleteWebhook)...)
+	router.GET("/v1/webhooks/:id/deliveries", append(admin, ListWebhookDeliveries)...)
+
+	router.GET("/v1/screening/hits", append(admin, ListScreeningHits)...)
+	router.GET("/v1/screening/hits/:id", append(admin, GetScreeningHit)...)
+	router.POST("/v1/screening/hits/:id/decision", append(admin, DecideScreeningHit)...)
+
+	router.GET("/v1/admin/cache", append(admin, GetCacheStats)...)
+	router.GET("/v1/admin/quality", append(admin, GetQualityReport)...)
+	router.POST("/v1/admin/quality/fix", append(admin, FixQuality)...)
+	router.GET("/healthz", Healthz)
+	router.GET("/readyz", Readyz)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/openapi.json", spec)
+	if opts.SwaggerUI {
+		router.GET("/docs", openapi.SwaggerUI)
+	}
+	return nil
+}
+
+// drops unset middleware, the result is clipped so every append copies it
+func chain(handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	out := make([]gin.HandlerFunc, 0, len(handlers))
+	for _, h := range handlers {
+		if h != nil {
+			out = append(out, h)
+		}
+	}
+	return slices.Clip(out)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+)
+
+const (
+	defaultHitLimit = 100
+	maxHitLimit     = 1000
+)
+
+type screeningHitList struct {
+	Hits []model.ScreeningHit `json:"hits"`
+	Next int64                `json:"next,omitempty"` // after of the next page, omitted on the last page
+}
+
+type screeningDecisionRequest struct {
+	Decision string `json:"decision"`
+	Comment  string `json:"comment"`
+}
+
+// screening hits ordered by ID, filtered by status, country and SWIFT code.
+// Pages continue after the ID in next.
+func ListScreeningHits(c *gin.Context) {
+	filter := database.ScreeningHitFilter{
+		Status:      c.Query("status"),
+		CountryCode: c.Query("country"),
+		SwiftCode:   c.Query("swiftCode"),
+	}
+	switch filter.Status {
+	case "", model.HitOpen, model.HitAccepted, model.HitDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "status must be open, accepted or dismissed"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHitLimit)))
+	if err != nil || limit < 1 || limit > maxHitLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "limit must be between 1 and " + strconv.Itoa(maxHitLimit)})
+		return
+	}
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "after must be a hit ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	hits, err := database.ListScreeningHitsContext(ctx, config.GetDB(), filter, after, limit+1)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when listing screening hits", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+
+	res := screeningHitList{Hits: hits}
+	if len(hits) > limit {
+		res.Hits = hits[:limit]
+		res.Next = hits[limit-1].ID
+	}
+	if res.Hits == nil {
+		res.Hits = []model.ScreeningHit{}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// a hit with its decisions
+func GetScreeningHit(c *gin.Context) {
+	id, ok := screeningHitID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	hit, err := database.GetScreeningHitContext(ctx, config.GetDB(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Screening hit not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when fetching a screening hit", "hit_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Database query error"})
+		return
+	}
+	c.JSON(http.StatusOK, hit)
+}
+
+// accepts a hit as a true match or dismisses it, every decision is kept in the audit log
+// under the name of the admin key it was made with
+func DecideScreeningHit(c *gin.Context) {
+	reviewer := auth.Principal(c)
+	if reviewer == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Admin API key required"})
+		return
+	}
+	id, ok := screeningHitID(c)
+	if !ok {
+		return
+	}
+
+	var req screeningDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+	if req.Decision != model.DecisionAccept && req.Decision != model.DecisionDismiss {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "decision must be accept or dismiss"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	decision := model.ScreeningDecision{Decision: req.Decision, Reviewer: reviewer, Comment: strings.TrimSpace(req.Comment)}
+	hit, err := database.DecideScreeningHitContext(ctx, config.GetDB(), id, decision)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Screening hit not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error when deciding a screening hit", "hit_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to record the decision"})
+		return
+	}
+
+	logging.FromContext(ctx).Info("Screening hit reviewed", "hit_id", id, "swift_code", hit.SwiftCode, "decision", req.Decision, "reviewer", reviewer)
+	// the screening status of the bank may have changed
+	directoryCache.InvalidateBank(hit.SwiftCode, hit.CountryCode)
+	c.JSON(http.StatusOK, hit)
+}
+
+func screeningHitID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid screening hit ID"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/webhook"
+)
+
+const deliveryLogLimit = 50
+
+// subscriptions to loopback, link-local and private addresses are refused unless allowed
+var allowPrivateWebhooks bool
+
+func SetAllowPrivateWebhooks(allow bool) {
+	allowPrivateWebhooks = allow
+}
+
+type webhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes"`
+}
+
+// subscribes a URL to directory events, the response is the only place the secret is shown
+func CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid JSON format"})
+		return
+	}
+
+	if err := webhook.CheckURL(c.Request.Context(), req.URL, allowPrivateWebhooks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	if len(req.EventTypes) == 0 {
+		req.EventTypes = model.EventTypes
+	}
+	for _, t := range req.EventTypes {
+		if !model.IsEventType(t) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown event type " + strconv.Quote(t)})
+			return
+		}
+	}
+	if req.Secret == "" {
+		req.Secret = webhook.NewSecret()
+	}
+
+	w := model.Webhook{URL: req.URL, Secret: req.Secret, EventTypes: req.EventTypes}
+	if err := database.CreateWebhookContext(c.Request.Context(), config.GetDB(), &w); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Error when creating webhook", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, w)
+}
+
+func ListWebhooks(c *gin.Context) {
+	webhooks, err := database.ListWebhooksContext(c.Request.Context(), config.GetDB())
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Error when listing webhooks", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to list webhooks"})
+		return
+	}
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	err := database.DeleteWebhookContext(c.Request.Context(), config.GetDB(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Error when deleting webhook", "webhook_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook successfully deleted"})
+}
+
+// the latest deliveries of a subscription with every attempt
+func ListWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := database.ListWebhookDeliveriesContext(c.Request.Context(), config.GetDB(), id, deliveryLogLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Error when listing webhook deliveries", "webhook_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to list webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid webhook ID"})
+		return 0, false
+	}
+	return id, true
+}
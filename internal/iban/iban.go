@@ -0,0 +1,254 @@
+package iban
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// reasons an IBAN is rejected
+var (
+	ErrCharacters = errors.New("IBAN may only contain letters and digits")
+	ErrCountry    = errors.New("country does not use IBANs or is unknown")
+	ErrLength     = errors.New("IBAN has the wrong length for its country")
+	ErrFormat     = errors.New("BBAN does not match the format of its country")
+	ErrChecksum   = errors.New("IBAN check digits are wrong")
+)
+
+// IBAN format of one country
+type spec struct {
+	country  string
+	length   int       // whole IBAN
+	bban     []segment // e.g. 4a6n8n
+	bankCode [2]int    // 0-based start and end of the bank code within the BBAN
+}
+
+type segment struct {
+	length int
+	kind   byte // n digits, a upper case letters, c letters or digits
+}
+
+// Registry holds the IBAN formats and the optional national bank code to BIC table
+type Registry struct {
+	specs map[string]spec
+	bics  map[string]string // country + bank code -> BIC
+}
+
+// Result describes a checked IBAN, Err is nil for a valid one
+type Result struct {
+	IBAN        string // normalized: upper case without spaces
+	CountryCode string
+	CheckDigits string
+	BBAN        string
+	BankCode    string
+	BIC         string // from the bank code table, empty if the code is not listed
+	Err         error
+}
+
+func (r Result) Valid() bool { return r.Err == nil }
+
+// Normalize removes spaces (and the usual print format grouping) and upper cases the IBAN
+func Normalize(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// ValidChecksum runs the ISO 7064 mod 97-10 check over a normalized IBAN
+func ValidChecksum(iban string) bool {
+	if len(iban) < 5 {
+		return false
+	}
+	// country code and check digits move to the end, letters count as 10..35
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for i := 0; i < len(rearranged); i++ {
+		ch := rearranged[i]
+		switch {
+		case ch >= '0' && ch <= '9':
+			remainder = (remainder*10 + int(ch-'0')) % 97
+		case ch >= 'A' && ch <= 'Z':
+			remainder = (remainder*100 + int(ch-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// Validate checks characters, country, length, BBAN structure and check digits
+// and looks up the BIC of the bank code
+func (r *Registry) Validate(s string) Result {
+	res := Result{IBAN: Normalize(s)}
+	iban := res.IBAN
+
+	for i := 0; i < len(iban); i++ {
+		if !isAlnum(iban[i]) {
+			res.Err = ErrCharacters
+			return res
+		}
+	}
+	if len(iban) < 4 {
+		res.Err = ErrLength
+		return res
+	}
+
+	res.CountryCode, res.CheckDigits, res.BBAN = iban[:2], iban[2:4], iban[4:]
+	sp, ok := r.specs[res.CountryCode]
+	if !ok {
+		res.Err = ErrCountry
+		return res
+	}
+	if len(iban) != sp.length {
+		res.Err = ErrLength
+		return res
+	}
+	if !sp.matches(res.BBAN) {
+		res.Err = ErrFormat
+		return res
+	}
+	if !ValidChecksum(iban) {
+		res.Err = ErrChecksum
+		return res
+	}
+
+	res.BankCode = res.BBAN[sp.bankCode[0]:sp.bankCode[1]]
+	res.BIC = r.bics[res.CountryCode+res.BankCode]
+	return res
+}
+
+// Countries returns the number of countries with a known IBAN format
+func (r *Registry) Countries() int {
+	return len(r.specs)
+}
+
+func (s spec) matches(bban string) bool {
+	pos := 0
+	for _, seg := range s.bban {
+		for i := 0; i < seg.length; i++ {
+			ch := bban[pos]
+			switch seg.kind {
+			case 'n':
+				if ch < '0' || ch > '9' {
+					return false
+				}
+			case 'a':
+				if ch < 'A' || ch > 'Z' {
+					return false
+				}
+			}
+			pos++
+		}
+	}
+	return pos == len(bban)
+}
+
+func isAlnum(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'A' && ch <= 'Z')
+}
+
+// LoadRegistry reads the IBAN formats, see data/iban_registry.csv
+func LoadRegistry(path string) (*Registry, error) {
+	records, err := readCSV(path, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{specs: map[string]spec{}, bics: map[string]string{}}
+	for _, rec := range records {
+		sp, err := parseSpec(rec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, rec[0], err)
+		}
+		r.specs[sp.country] = sp
+	}
+	return r, nil
+}
+
+// LoadBankCodes adds a national bank code to BIC table, see data/iban_bank_codes.csv.
+// It returns the number of codes read.
+func (r *Registry) LoadBankCodes(path string) (int, error) {
+	records, err := readCSV(path, 3)
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range records {
+		country, code, bic := strings.ToUpper(rec[0]), strings.ToUpper(rec[1]), strings.ToUpper(rec[2])
+		if len(bic) == 8 {
+			bic += "XXX"
+		}
+		r.bics[country+code] = bic
+	}
+	return len(records), nil
+}
+
+func parseSpec(rec []string) (spec, error) {
+	sp := spec{country: strings.ToUpper(rec[0])}
+	if len(sp.country) != 2 {
+		return sp, fmt.Errorf("invalid country code %q", rec[0])
+	}
+
+	var err error
+	if sp.length, err = strconv.Atoi(rec[1]); err != nil {
+		return sp, fmt.Errorf("invalid length %q", rec[1])
+	}
+
+	// segments like 4a6n8n
+	format, bbanLength := rec[2], 0
+	for format != "" {
+		i := strings.IndexAny(format, "nac")
+		if i < 1 {
+			return sp, fmt.Errorf("invalid BBAN format %q", rec[2])
+		}
+		n, err := strconv.Atoi(format[:i])
+		if err != nil || n < 1 {
+			return sp, fmt.Errorf("invalid BBAN format %q", rec[2])
+		}
+		sp.bban = append(sp.bban, segment{length: n, kind: format[i]})
+		bbanLength += n
+		format = format[i+1:]
+	}
+	if bbanLength+4 != sp.length {
+		return sp, fmt.Errorf("BBAN format %q does not add up to length %d", rec[2], sp.length)
+	}
+
+	from, to, ok := strings.Cut(rec[3], "-")
+	start, err1 := strconv.Atoi(from)
+	end, err2 := strconv.Atoi(to)
+	if !ok || err1 != nil || err2 != nil || start < 1 || end < start || end > bbanLength {
+		return sp, fmt.Errorf("invalid bank code position %q", rec[3])
+	}
+	sp.bankCode = [2]int{start - 1, end}
+	return sp, nil
+}
+
+// reads a CSV with a header row, lines starting with # are comments
+func readCSV(path string, fields int) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = fields
+	reader.TrimLeadingSpace = true
+
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var records [][]string
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,116 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/logging"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(logging.Middleware())
+	router.POST("/v1/swift-codes", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Error("Error when inserting new data", "swift_code", "TESTPLPWXXX")
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to insert SWIFT code"})
+	})
+	router.GET("/v1/iban/:iban/validate", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// decodes one JSON log line per entry
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestMiddleware_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := logging.Setup(&buf, "info", "json")
+	assert.NoError(t, err)
+
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-123")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-123", w.Header().Get(logging.RequestIDHeader), "Request ID should be echoed back")
+
+	lines := logLines(t, &buf)
+	assert.Len(t, lines, 2)
+
+	// handler log carries request ID and SWIFT code
+	assert.Equal(t, "Error when inserting new data", lines[0]["msg"])
+	assert.Equal(t, "req-123", lines[0]["request_id"])
+	assert.Equal(t, "TESTPLPWXXX", lines[0]["swift_code"])
+
+	// access log
+	assert.Equal(t, "request", lines[1]["msg"])
+	assert.Equal(t, "ERROR", lines[1]["level"])
+	assert.Equal(t, "req-123", lines[1]["request_id"])
+	assert.Equal(t, float64(500), lines[1]["status"])
+	assert.Equal(t, "/v1/swift-codes", lines[1]["route"])
+}
+
+func TestMiddleware_GeneratedRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := logging.Setup(&buf, "info", "json")
+	assert.NoError(t, err)
+
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/swift-codes", nil)
+	router.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(logging.RequestIDHeader)
+	assert.Len(t, requestID, 32, "A request ID should be generated")
+	assert.Equal(t, requestID, logLines(t, &buf)[0]["request_id"])
+}
+
+func TestMiddleware_RedactsIBAN(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := logging.Setup(&buf, "info", "json")
+	assert.NoError(t, err)
+
+	router := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/iban/PL61109010140000071219812874/validate", nil)
+	router.ServeHTTP(w, req)
+
+	assert.NotContains(t, buf.String(), "PL61109010140000071219812874")
+	assert.Equal(t, "/v1/iban/:iban/validate", logLines(t, &buf)[0]["path"])
+}
+
+func TestSetup_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.Setup(&buf, "warn", "text")
+	assert.NoError(t, err)
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown")
+
+	_, err = logging.Setup(&buf, "loud", "json")
+	assert.Error(t, err)
+	_, err = logging.Setup(&buf, "info", "xml")
+	assert.Error(t, err)
+}
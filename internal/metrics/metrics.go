@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/white67/swift_api/internal/cache"
+)
+
+const namespace = "swift_api"
+
+// Registry holds every metric exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of internal/database functions.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"function", "result"})
+
+	importRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "import_rows_total",
+		Help:      "Rows processed by imports by result.",
+	}, []string{"result"})
+
+	importDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "import_duration_seconds",
+		Help:      "Duration of complete imports.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbQueryDuration,
+		importRows,
+		importDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware records count and latency of every request.
+// Requests that did not match a route are grouped under "unmatched" to keep cardinality low.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveQuery is deferred at the start of a database function:
+//
+//	defer metrics.ObserveQuery("GetBankBySwiftCode", time.Now(), &err)
+func ObserveQuery(function string, start time.Time, err *error) {
+	result := "ok"
+	if err != nil && *err != nil {
+		result = "error"
+		if errors.Is(*err, sql.ErrNoRows) {
+			result = "not_found"
+		}
+	}
+	dbQueryDuration.WithLabelValues(function, result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveImport records the outcome of a finished import
+func ObserveImport(inserted, failed int, duration time.Duration) {
+	importRows.WithLabelValues("inserted").Add(float64(inserted))
+	importRows.WithLabelValues("failed").Add(float64(failed))
+	importDuration.Observe(duration.Seconds())
+}
+
+// RegisterDB exposes the connection pool statistics from sql.DB.Stats()
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCache exposes hit/miss counters of the lookup caches
+func RegisterCache(dir *cache.Directory) error {
+	return Registry.Register(&cacheCollector{dir: dir})
+}
+
+var (
+	cacheHitsDesc      = prometheus.NewDesc(namespace+"_cache_hits_total", "Lookup cache hits.", []string{"cache"}, nil)
+	cacheMissesDesc    = prometheus.NewDesc(namespace+"_cache_misses_total", "Lookup cache misses.", []string{"cache"}, nil)
+	cacheEvictionsDesc = prometheus.NewDesc(namespace+"_cache_evictions_total", "Lookup cache evictions.", []string{"cache"}, nil)
+	cacheSizeDesc      = prometheus.NewDesc(namespace+"_cache_entries", "Entries currently in the lookup cache.", []string{"cache"}, nil)
+	cacheRatioDesc     = prometheus.NewDesc(namespace+"_cache_hit_ratio", "Hits divided by lookups since start.", []string{"cache"}, nil)
+)
+
+type cacheCollector struct {
+	dir *cache.Directory
+}
+
+func (cc *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheSizeDesc
+	ch <- cacheRatioDesc
+}
+
+func (cc *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, s := range cc.dir.Stats() {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(s.Hits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(s.Misses), name)
+		ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(s.Evictions), name)
+		ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(s.Size), name)
+		ch <- prometheus.MustNewConstMetric(cacheRatioDesc, prometheus.GaugeValue, s.HitRatio, name)
+	}
+}
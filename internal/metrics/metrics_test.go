@@ -0,0 +1,86 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/cache"
+	"github.com/white67/swift_api/internal/metrics"
+)
+
+func scrape(t *testing.T) string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(metrics.Middleware())
+	router.GET("/v1/swift-codes/:swiftCode", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SWIFT code not found"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/NONEXISTENT", nil)
+	router.ServeHTTP(w, req)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/unknown/path", nil)
+	router.ServeHTTP(w, req)
+
+	body := scrape(t)
+	// labelled by route template, not by the requested code
+	assert.Contains(t, body, `swift_api_http_requests_total{method="GET",route="/v1/swift-codes/:swiftCode",status="404"} 1`)
+	assert.Contains(t, body, `swift_api_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `swift_api_http_request_duration_seconds_count{method="GET",route="/v1/swift-codes/:swiftCode",status="404"} 1`)
+}
+
+func TestObserveQuery(t *testing.T) {
+	func() (err error) {
+		defer metrics.ObserveQuery("TestQuery", time.Now(), &err)
+		return sql.ErrNoRows
+	}()
+	func() (err error) {
+		defer metrics.ObserveQuery("TestQuery", time.Now(), &err)
+		return errors.New("connection refused")
+	}()
+
+	body := scrape(t)
+	assert.Contains(t, body, `swift_api_db_query_duration_seconds_count{function="TestQuery",result="not_found"} 1`)
+	assert.Contains(t, body, `swift_api_db_query_duration_seconds_count{function="TestQuery",result="error"} 1`)
+}
+
+func TestObserveImport(t *testing.T) {
+	metrics.ObserveImport(10, 2, time.Second)
+
+	body := scrape(t)
+	assert.Contains(t, body, `swift_api_import_rows_total{result="inserted"} 10`)
+	assert.Contains(t, body, `swift_api_import_rows_total{result="failed"} 2`)
+	assert.Contains(t, body, "swift_api_import_duration_seconds_count 1")
+}
+
+func TestRegisterCache(t *testing.T) {
+	dir := cache.NewDirectory(10, 0)
+	assert.NoError(t, metrics.RegisterCache(dir))
+
+	dir.Codes.Set("TESTPLPWXXX", "hq")
+	dir.Codes.Get("TESTPLPWXXX")
+	dir.Codes.Get("TESTPLPW123")
+
+	body := scrape(t)
+	assert.Contains(t, body, `swift_api_cache_hits_total{cache="codes"} 1`)
+	assert.Contains(t, body, `swift_api_cache_misses_total{cache="codes"} 1`)
+	assert.Contains(t, body, `swift_api_cache_hit_ratio{cache="codes"} 0.5`)
+	assert.True(t, strings.Contains(body, `swift_api_cache_entries{cache="countries"} 0`))
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// directory change event types
+const (
+	EventCreated         = "created"
+	EventUpdated         = "updated"
+	EventDeleted         = "deleted"
+	EventImportCompleted = "import-completed"
+)
+
+var EventTypes = []string{EventCreated, EventUpdated, EventDeleted, EventImportCompleted}
+
+func IsEventType(s string) bool {
+	return slices.Contains(EventTypes, s)
+}
+
+// Event is a persisted change of the directory, IDs increase with every change
+type Event struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	SwiftCode   string          `json:"swiftCode,omitempty"`
+	CountryCode string          `json:"countryISO2,omitempty"`
+	Data        json.RawMessage `json:"data"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// DateLayout is the format of calendar dates, e.g. holidays
+const DateLayout = "2006-01-02"
+
+// Holiday is a bank holiday of a country, banks of that country are closed all day
+type Holiday struct {
+	CountryCode string `json:"countryISO2"`
+	Date        string `json:"date"` // DateLayout
+	Name        string `json:"name"`
+}
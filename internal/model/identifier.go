@@ -0,0 +1,103 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// national bank identifier schemes
+const (
+	SchemeSortCode = "sort-code" // UK sort code, 6 digits
+	SchemeBLZ      = "blz"       // German Bankleitzahl, 8 digits
+	SchemeABA      = "aba"       // US ABA routing number, 9 digits with check digit
+	SchemeBSB      = "bsb"       // Australian bank-state-branch number, 6 digits
+	SchemeIFSC     = "ifsc"      // Indian financial system code, 11 characters
+)
+
+// IdentifierSchemes lists the supported schemes
+var IdentifierSchemes = []string{SchemeSortCode, SchemeBLZ, SchemeABA, SchemeBSB, SchemeIFSC}
+
+var (
+	ErrUnknownScheme     = errors.New("unknown identifier scheme")
+	ErrInvalidIdentifier = errors.New("invalid identifier for its scheme")
+)
+
+// BankIdentifier links a national identifier to a SWIFT code of the directory
+type BankIdentifier struct {
+	Scheme     string `json:"scheme"`
+	Identifier string `json:"identifier"`
+	SwiftCode  string `json:"swiftCode"`
+}
+
+func IsIdentifierScheme(s string) bool {
+	return slices.Contains(IdentifierSchemes, s)
+}
+
+// NormalizeIdentifier removes spaces and dashes (20-00-00), upper cases the
+// identifier and checks it against the format of its scheme
+func NormalizeIdentifier(scheme, id string) (string, error) {
+	if !IsIdentifierScheme(scheme) {
+		return "", ErrUnknownScheme
+	}
+	id = strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, id))
+
+	var valid bool
+	switch scheme {
+	case SchemeSortCode, SchemeBSB:
+		valid = len(id) == 6 && allDigits(id)
+	case SchemeBLZ:
+		valid = len(id) == 8 && allDigits(id)
+	case SchemeABA:
+		valid = len(id) == 9 && allDigits(id) && abaChecksum(id)
+	case SchemeIFSC:
+		// 4 letter bank code, a zero, 6 character branch code
+		valid = len(id) == 11 && allLetters(id[:4]) && id[4] == '0' && allAlnum(id[5:])
+	}
+	if !valid {
+		return "", ErrInvalidIdentifier
+	}
+	return id, nil
+}
+
+// weights 3 7 1, the sum is a multiple of 10
+func abaChecksum(id string) bool {
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < len(id); i++ {
+		sum += int(id[i]-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func allLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func allAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !(s[i] >= '0' && s[i] <= '9') && !(s[i] >= 'A' && s[i] <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
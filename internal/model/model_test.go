@@ -36,4 +36,31 @@ func TestTypeHeadquarters(t *testing.T) {
 			assert.Equal(t, tc.expected, result, "Should correctly identify if SWIFT code represents headquarters or not")
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		scheme   string
+		id       string
+		expected string
+		err      error
+	}{
+		{model.SchemeSortCode, "20-00-00", "200000", nil},
+		{model.SchemeSortCode, "20 00 0", "", model.ErrInvalidIdentifier},
+		{model.SchemeBLZ, "370 400 44", "37040044", nil},
+		{model.SchemeBLZ, "3704004A", "", model.ErrInvalidIdentifier},
+		{model.SchemeABA, "021000021", "021000021", nil},
+		{model.SchemeABA, "021000022", "", model.ErrInvalidIdentifier}, // check digit
+		{model.SchemeBSB, "062-000", "062000", nil},
+		{model.SchemeIFSC, "sbin0000691", "SBIN0000691", nil},
+		{model.SchemeIFSC, "SBIN1000691", "", model.ErrInvalidIdentifier},
+		{"routing", "021000021", "", model.ErrUnknownScheme},
+	}
+	for _, tc := range tests {
+		t.Run(tc.scheme+" "+tc.id, func(t *testing.T) {
+			id, err := model.NormalizeIdentifier(tc.scheme, tc.id)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.expected, id)
+		})
+	}
+}
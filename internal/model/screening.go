@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// screening status of a directory entry
+const (
+	ScreeningNotScreened    = "not-screened"    // not screened since it was added or last changed
+	ScreeningClear          = "clear"           // screened without hits to review
+	ScreeningPotentialMatch = "potential-match" // open hits wait for a review
+	ScreeningMatch          = "match"           // a reviewer accepted a hit
+)
+
+// review status of a screening hit
+const (
+	HitOpen      = "open"
+	HitAccepted  = "accepted"
+	HitDismissed = "dismissed"
+)
+
+// decisions of a reviewer on a hit
+const (
+	DecisionAccept  = "accept"
+	DecisionDismiss = "dismiss"
+)
+
+// SanctionEntry is a sanctioned entity of an imported sanctions list
+type SanctionEntry struct {
+	List      string   `json:"list"`
+	EntryID   string   `json:"entryId"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Countries []string `json:"countries,omitempty"` // ISO2 codes, empty when the entry is not tied to a country
+	Program   string   `json:"program,omitempty"`
+}
+
+// ScreeningHit is a directory entry whose name matches a sanctions list entry
+type ScreeningHit struct {
+	ID          int64               `json:"id"`
+	SwiftCode   string              `json:"swiftCode"`
+	CountryCode string              `json:"countryISO2"`
+	BankName    string              `json:"bankName"` // name of the bank when it was screened
+	List        string              `json:"list"`
+	EntryID     string              `json:"entryId"`
+	EntryName   string              `json:"entryName"`
+	MatchedName string              `json:"matchedName"` // name or alias of the entry that matched
+	Program     string              `json:"program,omitempty"`
+	Score       float64             `json:"score"`
+	Status      string              `json:"status"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+	Decisions   []ScreeningDecision `json:"decisions,omitempty"`
+}
+
+// ScreeningDecision is an audited review of a hit
+type ScreeningDecision struct {
+	Decision  string    `json:"decision"`
+	Reviewer  string    `json:"reviewer"`
+	Comment   string    `json:"comment,omitempty"`
+	DecidedAt time.Time `json:"decidedAt"`
+}
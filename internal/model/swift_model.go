@@ -1,12 +1,52 @@
 package model
 
+import "time"
+
 type Bank struct {
-	Address       string `json:"address"`
-	Name          string `json:"bankName"`
-	CountryCode   string `json:"countryISO2"`
-	CountryName   string `json:"countryName,omitempty"`
-	IsHeadquarter bool   `json:"isHeadquarter"`
-	SwiftCode     string `json:"swiftCode"`
+	Address         string         `json:"address"`
+	Name            string         `json:"bankName"`
+	CountryCode     string         `json:"countryISO2"`
+	CountryName     string         `json:"countryName,omitempty"`
+	IsHeadquarter   bool           `json:"isHeadquarter"`
+	SwiftCode       string         `json:"swiftCode"`
+	TownName        string         `json:"townName,omitempty"`
+	Location        *Location      `json:"location,omitempty"`        // geocoded from the town, nil when unknown
+	TimeZone        string         `json:"timeZone,omitempty"`        // IANA name, e.g. Europe/Warsaw
+	ScreeningStatus string         `json:"screeningStatus,omitempty"` // set on lookups, see the Screening* constants
+	PostalAddress   *PostalAddress `json:"postalAddress,omitempty"`   // parsed from Address, nil when nothing was recognised
+	UpdatedAt       time.Time      `json:"-"`
+}
+
+// PostalAddress is Address split into the structured parts of an ISO 20022 postal address,
+// parts that were not recognised are empty
+type PostalAddress struct {
+	StreetName         string `json:"streetName,omitempty"`
+	BuildingNumber     string `json:"buildingNumber,omitempty"`
+	PostCode           string `json:"postCode,omitempty"`
+	TownName           string `json:"townName,omitempty"`
+	CountrySubDivision string `json:"countrySubDivision,omitempty"`
+}
+
+// NearbyBank is a result of a distance search
+type NearbyBank struct {
+	Bank
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// Location is a WGS84 coordinate in decimal degrees
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// IsTimeZone reports whether name is an IANA time zone such as Europe/Warsaw,
+// "" and "Local" are not accepted
+func IsTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
 }
 
 // last 3 letters in Code = branch code (if not XXX)
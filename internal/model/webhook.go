@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Webhook is a subscription to directory events, the secret signs every delivery
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery is one event sent to one subscription
+type WebhookDelivery struct {
+	ID            int64            `json:"id"`
+	EventID       int64            `json:"eventId"`
+	EventType     string           `json:"eventType"`
+	Status        string           `json:"status"` // pending, delivered or failed
+	Attempts      []WebhookAttempt `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time        `json:"createdAt"`
+}
+
+// WebhookAttempt is a single HTTP request of a delivery
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"durationMs"`
+}
@@ -0,0 +1,133 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+// the maintained API description, client SDKs are generated from it
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed swagger.html
+var swaggerHTML []byte
+
+func init() {
+	// keep validation errors to one line instead of dumping the schema
+	openapi3.SchemaErrorDetailsDisabled = true
+}
+
+// Load parses and validates the embedded specification
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Handler serves the specification as JSON
+func Handler() (gin.HandlerFunc, error) {
+	doc, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	body, err := doc.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	}, nil
+}
+
+// SwaggerUI serves a Swagger UI page for /openapi.json (assets are loaded from a CDN)
+func SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerHTML)
+}
+
+// Validator rejects requests that do not match the specification with 400.
+// Routes that are not in the specification are passed through.
+func Validator() (gin.HandlerFunc, error) {
+	doc, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	// match on the path only, not on the example server URL
+	doc.Servers = nil
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			// undocumented path or method, gin decides
+			c.Next()
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": validationMessage(err)})
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// short description of the first problem
+func validationMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case reqErr.Parameter != nil && errors.As(reqErr.Err, &schemaErr):
+		return "Invalid " + reqErr.Parameter.In + " parameter " + reqErr.Parameter.Name + ": " + schemaErr.Reason
+	case reqErr.Parameter != nil:
+		return "Invalid " + reqErr.Parameter.In + " parameter " + reqErr.Parameter.Name
+	case errors.As(reqErr.Err, &schemaErr):
+		if field := schemaErr.JSONPointer(); len(field) > 0 && !strings.Contains(schemaErr.Reason, `"`+field[len(field)-1]+`"`) {
+			return "Invalid request body: " + jsonPath(field) + " " + schemaErr.Reason
+		}
+		return "Invalid request body: " + schemaErr.Reason
+	case reqErr.RequestBody != nil:
+		return "Invalid JSON format"
+	}
+	return reqErr.Error()
+}
+
+func jsonPath(pointer []string) string {
+	path := pointer[0]
+	for _, p := range pointer[1:] {
+		path += "." + p
+	}
+	return path
+}
@@ -0,0 +1,106 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/handler"
+	"github.com/white67/swift_api/internal/openapi"
+)
+
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+// every route the server registers must be documented
+func TestSpec_CoversAllRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	assert.NoError(t, handler.RegisterRoutes(router, handler.RouteOptions{SwaggerUI: true}))
+
+	for _, route := range router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		item := doc.Paths.Find(path)
+		if !assert.NotNil(t, item, "%s %s is missing from openapi.yaml", route.Method, path) {
+			continue
+		}
+		assert.NotNil(t, item.GetOperation(route.Method), "%s %s is missing from openapi.yaml", route.Method, path)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	assert.NoError(t, handler.RegisterRoutes(router, handler.RouteOptions{}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var spec map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+	assert.Contains(t, spec["components"].(map[string]any)["schemas"], "Bank")
+
+	// Swagger UI is off unless enabled
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestValidator(t *testing.T) {
+	validate, err := openapi.Validator()
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(validate)
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/v1/swift-codes/export", ok)
+	router.GET("/v1/swift-codes/:swiftCode", ok)
+	router.POST("/v1/swift-codes", ok)
+	router.GET("/undocumented", ok)
+
+	tests := []struct {
+		name, method, path, body string
+		status                   int
+		message                  string
+	}{
+		{"valid lookup", "GET", "/v1/swift-codes/AAISALTRXXX", "", http.StatusNoContent, ""},
+		{"short code", "GET", "/v1/swift-codes/AAISAL", "", http.StatusBadRequest, "parameter swiftCode"},
+		{"static route wins", "GET", "/v1/swift-codes/export?format=jsonl", "", http.StatusNoContent, ""},
+		{"bad enum", "GET", "/v1/swift-codes/export?format=xml", "", http.StatusBadRequest, "parameter format"},
+		{"valid body", "POST", "/v1/swift-codes", `{"swiftCode":"TESTPLPWXXX","bankName":"Test","countryISO2":"PL","isHeadquarter":true}`, http.StatusNoContent, ""},
+		{"missing field", "POST", "/v1/swift-codes", `{"swiftCode":"TESTPLPWXXX","countryISO2":"PL"}`, http.StatusBadRequest, "bankName"},
+		{"wrong type", "POST", "/v1/swift-codes", `{"swiftCode":"TESTPLPWXXX","bankName":"Test","countryISO2":"PL","isHeadquarter":"yes"}`, http.StatusBadRequest, "isHeadquarter"},
+		{"broken JSON", "POST", "/v1/swift-codes", `{"swiftCode":`, http.StatusBadRequest, "Invalid JSON format"},
+		{"not in spec", "GET", "/undocumented", "", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.message != "" {
+				var response map[string]string
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Contains(t, response["message"], tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// longest iCal event that is expanded into single holidays
+const maxHolidayDays = 31
+
+// ParseHolidayFile reads an iCalendar file (.ics) as the holidays of countryCode or a CSV
+// file with the holidays of several countries; with a countryCode only the holidays of
+// that country are kept from it
+func ParseHolidayFile(path, countryCode string) ([]model.Holiday, error) {
+	countryCode = strings.ToUpper(countryCode)
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		return ParseICal(path, countryCode)
+	}
+	holidays, err := ParseHolidayCSV(path)
+	if err != nil || countryCode == "" {
+		return holidays, err
+	}
+	var kept []model.Holiday
+	for _, h := range holidays {
+		if h.CountryCode == countryCode {
+			kept = append(kept, h)
+		}
+	}
+	return kept, nil
+}
+
+// ParseHolidayCSV reads a holiday file with the columns COUNTRY ISO2 CODE, DATE
+// (YYYY-MM-DD) and NAME, lines starting with # are comments
+func ParseHolidayCSV(path string) ([]model.Holiday, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 3
+
+	// header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	var result []model.Holiday
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		countryCode := strings.ToUpper(strings.TrimSpace(record[0]))
+		if !isCountryCode(countryCode) {
+			return nil, fmt.Errorf("line %d: invalid country code %q", line, record[0])
+		}
+		date, err := time.Parse(model.DateLayout, strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q, expected YYYY-MM-DD", line, record[1])
+		}
+
+		result = append(result, model.Holiday{CountryCode: countryCode, Date: date.Format(model.DateLayout), Name: strings.TrimSpace(record[2])})
+	}
+	return result, nil
+}
+
+// ParseICal reads the events of an iCalendar (.ics) file as holidays of one country.
+// All-day events spanning several days become one holiday per day, recurring
+// events (RRULE) are rejected because their occurrences are not expanded.
+func ParseICal(path, countryCode string) ([]model.Holiday, error) {
+	countryCode = strings.ToUpper(countryCode)
+	if !isCountryCode(countryCode) {
+		return nil, fmt.Errorf("invalid country code %q", countryCode)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines, err := unfoldICal(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.Holiday
+	var event map[string]icalProperty // nil outside of VEVENT
+	var eventLine int
+	for _, l := range lines {
+		name, prop, ok := parseICalLine(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: malformed line %q", l.number, l.text)
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event, eventLine = map[string]icalProperty{}, l.number
+		case name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if event == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", l.number)
+			}
+			days, err := eventHolidays(event, countryCode)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", eventLine, err)
+			}
+			result = append(result, days...)
+			event = nil
+		case event != nil:
+			// the first occurrence of a property counts
+			if _, seen := event[name]; !seen {
+				event[name] = prop
+			}
+		}
+	}
+	if event != nil {
+		return nil, fmt.Errorf("line %d: VEVENT is not closed", eventLine)
+	}
+	return result, nil
+}
+
+type icalLine struct {
+	number int
+	text   string
+}
+
+// joins folded lines (continuation lines start with a space or a tab)
+func unfoldICal(r io.Reader) ([]icalLine, error) {
+	var lines []icalLine
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text != "" && (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, icalLine{number: number, text: text})
+	}
+	return lines, scanner.Err()
+}
+
+type icalProperty struct {
+	params string // upper cased, e.g. ;VALUE=DATE
+	value  string
+}
+
+// splits NAME;PARAMS:VALUE, colons inside quoted parameter values do not end the name
+func parseICalLine(s string) (string, icalProperty, bool) {
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			head := s[:i]
+			name, params, _ := strings.Cut(head, ";")
+			if name == "" {
+				return "", icalProperty{}, false
+			}
+			return strings.ToUpper(name), icalProperty{params: strings.ToUpper(params), value: s[i+1:]}, true
+		}
+	}
+	return "", icalProperty{}, false
+}
+
+func eventHolidays(event map[string]icalProperty, countryCode string) ([]model.Holiday, error) {
+	if _, ok := event["RRULE"]; ok {
+		return nil, errors.New("recurring events are not supported, list every occurrence")
+	}
+	start, ok := event["DTSTART"]
+	if !ok {
+		return nil, errors.New("event without DTSTART")
+	}
+	first, err := icalDate(start.value)
+	if err != nil {
+		return nil, err
+	}
+
+	// DTEND of an all-day event is the day after the last day
+	last := first
+	if end, ok := event["DTEND"]; ok && isICalDate(end) {
+		endDate, err := icalDate(end.value)
+		if err != nil {
+			return nil, err
+		}
+		last = endDate.AddDate(0, 0, -1)
+	}
+	if last.Before(first) {
+		last = first
+	}
+	if last.Sub(first) >= maxHolidayDays*24*time.Hour {
+		return nil, fmt.Errorf("event longer than %d days", maxHolidayDays)
+	}
+
+	name := unescapeICal(event["SUMMARY"].value)
+	var days []model.Holiday
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		days = append(days, model.Holiday{CountryCode: countryCode, Date: d.Format(model.DateLayout), Name: name})
+	}
+	return days, nil
+}
+
+// a date value (VALUE=DATE or just 8 digits) as opposed to a date-time
+func isICalDate(p icalProperty) bool {
+	return strings.Contains(p.params, "VALUE=DATE") && !strings.Contains(p.params, "VALUE=DATE-TIME") || len(p.value) == 8
+}
+
+// the date of a DATE (20260101) or DATE-TIME (20260101T000000Z) value
+func icalDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	d, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return d, nil
+}
+
+var icalEscapes = strings.NewReplacer(`\\`, `\`, `\,`, `,`, `\;`, `;`, `\n`, " ", `\N`, " ")
+
+func unescapeICal(s string) string {
+	return strings.TrimSpace(icalEscapes.Replace(s))
+}
+
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
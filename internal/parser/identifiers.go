@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// ParseIdentifierCSV reads a national identifier mapping file with the columns
+// SCHEME, IDENTIFIER and SWIFT CODE. Identifiers are normalized, a row with an
+// unknown scheme or a malformed identifier or SWIFT code stops the parse.
+func ParseIdentifierCSV(path string) ([]model.BankIdentifier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 3
+
+	// header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	var result []model.BankIdentifier
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		scheme := strings.ToLower(strings.TrimSpace(record[0]))
+		id, err := model.NormalizeIdentifier(scheme, record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: %s %q", line, err, scheme, record[1])
+		}
+		swiftCode := strings.ToUpper(strings.TrimSpace(record[2]))
+		if !isSwiftCode(swiftCode) {
+			return nil, fmt.Errorf("line %d: SWIFT code %q must have 11 letters or digits", line, record[2])
+		}
+
+		result = append(result, model.BankIdentifier{Scheme: scheme, Identifier: id, SwiftCode: swiftCode})
+	}
+	return result, nil
+}
@@ -40,8 +40,14 @@ func ParseSwiftCSV(path string) ([]model.Bank, error) {
 		swiftCode := record[1]
 		bankName := record[3]
 		address := record[4]
+		townName := strings.ToUpper(strings.TrimSpace(record[5]))
 		countryName := record[6]
 		countryName = strings.ToUpper(countryName)
+		// files without the TIME ZONE column or with an unknown zone store none
+		var timeZone string
+		if len(record) > 7 && model.IsTimeZone(strings.TrimSpace(record[7])) {
+			timeZone = strings.TrimSpace(record[7])
+		}
 
 		swift := model.Bank{
 			Address:       address,
@@ -50,6 +56,8 @@ func ParseSwiftCSV(path string) ([]model.Bank, error) {
 			CountryName:   countryName,
 			SwiftCode:     swiftCode,
 			IsHeadquarter: model.TypeHeadquarters(swiftCode),
+			TownName:      townName,
+			TimeZone:      timeZone,
 		}
 
 		result = append(result, swift)
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/model"
 	"github.com/white67/swift_api/internal/parser"
 )
 
@@ -35,6 +36,7 @@ FR,TESTFRPPXXX,BIC11,Test Bank France,France Address,Nice,France
 	assert.Equal(t, "Test Bank Poland", banks[0].Name)
 	assert.Equal(t, "Test Address 1", banks[0].Address)
 	assert.Equal(t, "POLAND", banks[0].CountryName)
+	assert.Equal(t, "MIELNO", banks[0].TownName)
 	assert.True(t, banks[0].IsHeadquarter)
 
 	// Check second bank
@@ -48,7 +50,199 @@ FR,TESTFRPPXXX,BIC11,Test Bank France,France Address,Nice,France
 	assert.True(t, banks[2].IsHeadquarter)
 }
 
+func TestParseSwiftCSV_TimeZone(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "swift_codes.csv")
+	csvContent := `COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE
+PL,TESTPLPWXXX,BIC11,Test Bank Poland,Test Address 1,Mielno,Poland, Europe/Warsaw
+US,TESTUSNYXXX,BIC11,Test Bank USA,USA Address 1,Dallas,United States,Mars/Olympus
+FR,TESTFRPPXXX,BIC11,Test Bank France,France Address,Nice,France,
+`
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent), 0644))
+
+	banks, err := parser.ParseSwiftCSV(tempFile)
+	assert.NoError(t, err)
+	assert.Len(t, banks, 3)
+	assert.Equal(t, "Europe/Warsaw", banks[0].TimeZone)
+	assert.Empty(t, banks[1].TimeZone, "unknown zones are dropped")
+	assert.Empty(t, banks[2].TimeZone)
+}
+
 func TestParseSwiftCSV_FileNotFound(t *testing.T) {
 	_, err := parser.ParseSwiftCSV("non_existent_file.csv")
 	assert.Error(t, err, "Parser should return an error for non-existent file")
 }
+
+func TestValidateSwiftCSV(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "swift_codes.csv")
+	csvContent := `Country,SWIFT Code,Code Type,Bank Name,Address,Town,Country Name
+PL,TESTPLPWXXX,BIC11,Test Bank Poland,Test Address 1,Mielno,Poland
+PL,TESTPLPW123,BIC11,Test Bank Poland,Test Address 2,Mielno,Poland
+PL,OTHRPLPW123,BIC11,Other Bank,Other Address,Mielno,Poland
+PL,TESTPLPWXXX,BIC11,Test Bank Poland,Test Address 1,Mielno,Poland
+DE,TESTPLPW456,BIC11,Test Bank Poland,Test Address 3,Berlin,Germany
+US,TEST,BIC11,Test Bank USA,USA Address 1,Dallas,United States
+FR,TESTFRPPXXX,BIC11,,France Address,Nice,France
+FR,TESTFRPP
+`
+	err := os.WriteFile(tempFile, []byte(csvContent), 0644)
+	assert.NoError(t, err)
+
+	report, err := parser.ValidateSwiftCSV(tempFile)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, report.Rows)
+	assert.Equal(t, 3, report.Valid)
+	assert.Equal(t, 1, report.Headquarters)
+	assert.Equal(t, 2, report.Branches)
+	assert.Equal(t, 1, report.Countries)
+	assert.Equal(t, []string{"OTHRPLPW123"}, report.OrphanBranches)
+
+	lines := map[int]string{}
+	for _, p := range report.Problems {
+		lines[p.Line] = p.Message
+	}
+	assert.Equal(t, "duplicate of line 2", lines[5])
+	assert.Contains(t, lines[6], "does not match DE")
+	assert.Contains(t, lines[7], "11 upper case")
+	assert.Equal(t, "bank name is empty", lines[8])
+	assert.Contains(t, lines[9], "expected 7 columns")
+}
+
+func TestParseIdentifierCSV(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "identifiers.csv")
+	csvContent := `SCHEME,IDENTIFIER,SWIFT CODE
+sort-code,20-00-00,testgb2lxxx
+BLZ, 370 400 44 ,TESTDEFFXXX
+`
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent), 0644))
+
+	ids, err := parser.ParseIdentifierCSV(tempFile)
+	assert.NoError(t, err)
+	if assert.Len(t, ids, 2) {
+		assert.Equal(t, model.BankIdentifier{Scheme: "sort-code", Identifier: "200000", SwiftCode: "TESTGB2LXXX"}, ids[0])
+		assert.Equal(t, model.BankIdentifier{Scheme: "blz", Identifier: "37040044", SwiftCode: "TESTDEFFXXX"}, ids[1])
+	}
+
+	// rows with errors stop the parse and name their line
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent+"aba,021000022,TESTUS33XXX\n"), 0644))
+	_, err = parser.ParseIdentifierCSV(tempFile)
+	assert.ErrorIs(t, err, model.ErrInvalidIdentifier)
+	assert.ErrorContains(t, err, "line 4")
+
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent+"blz,37040044,TESTDEFF\n"), 0644))
+	_, err = parser.ParseIdentifierCSV(tempFile)
+	assert.ErrorContains(t, err, "line 4")
+}
+
+func TestParseHolidayCSV(t *testing.T) {
+	holidays, err := parser.ParseHolidayCSV("../../data/holidays.csv")
+	assert.NoError(t, err)
+	assert.Contains(t, holidays, model.Holiday{CountryCode: "PL", Date: "2026-11-11", Name: "Independence Day"})
+
+	tempFile := filepath.Join(t.TempDir(), "holidays.csv")
+	assert.NoError(t, os.WriteFile(tempFile, []byte("COUNTRY ISO2 CODE,DATE,NAME\n# comment\nPL,2026-13-01,Nope\n"), 0644))
+	_, err = parser.ParseHolidayCSV(tempFile)
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestParseHolidayFile(t *testing.T) {
+	holidays, err := parser.ParseHolidayFile("../../data/holidays.csv", "pl")
+	assert.NoError(t, err)
+	assert.Contains(t, holidays, model.Holiday{CountryCode: "PL", Date: "2026-11-11", Name: "Independence Day"})
+	for _, h := range holidays {
+		assert.Equal(t, "PL", h.CountryCode, "Only the holidays of the country are kept")
+	}
+
+	all, err := parser.ParseHolidayFile("../../data/holidays.csv", "")
+	assert.NoError(t, err)
+	assert.Greater(t, len(all), len(holidays))
+}
+
+func TestParseICal(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "holidays.ics")
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20260501\r\nDTEND;VALUE=DATE:20260502\r\nSUMMARY:Labour Day\r\nEND:VEVENT\r\n" +
+		// folded summary with escapes, two days
+		"BEGIN:VEVENT\r\nSUMMARY:Christmas\\, first and\r\n  second day\r\nDTSTART;VALUE=DATE:20261225\r\nDTEND;VALUE=DATE:20261227\r\nEND:VEVENT\r\n" +
+		// date-time start without an end
+		"BEGIN:VEVENT\r\nDTSTART:20261111T000000Z\r\nSUMMARY:Independence Day\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	assert.NoError(t, os.WriteFile(tempFile, []byte(ics), 0644))
+
+	holidays, err := parser.ParseICal(tempFile, "pl")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Holiday{
+		{CountryCode: "PL", Date: "2026-05-01", Name: "Labour Day"},
+		{CountryCode: "PL", Date: "2026-12-25", Name: "Christmas, first and second day"},
+		{CountryCode: "PL", Date: "2026-12-26", Name: "Christmas, first and second day"},
+		{CountryCode: "PL", Date: "2026-11-11", Name: "Independence Day"},
+	}, holidays)
+
+	_, err = parser.ParseICal(tempFile, "")
+	assert.Error(t, err, "iCalendar files need a country")
+
+	recurring := filepath.Join(t.TempDir(), "recurring.ics")
+	assert.NoError(t, os.WriteFile(recurring, []byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20260101\nRRULE:FREQ=YEARLY\nSUMMARY:New Year\nEND:VEVENT\nEND:VCALENDAR\n"), 0644))
+	_, err = parser.ParseICal(recurring, "PL")
+	assert.ErrorContains(t, err, "recurring")
+}
+
+func TestParseSanctionCSV(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "sanctions.csv")
+	csvContent := "ID,NAME,ALIASES,COUNTRIES,PROGRAM\n" +
+		"# comment\n" +
+		"1,Bank Melli Iran,Melli Bank; BMI,ir;DE,IRAN\n" +
+		"2,Example Trading Company,,,\n"
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent), 0644))
+
+	entries, err := parser.ParseSanctionCSV(tempFile, "local")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.SanctionEntry{
+		{List: "local", EntryID: "1", Name: "Bank Melli Iran", Aliases: []string{"Melli Bank", "BMI"}, Countries: []string{"IR", "DE"}, Program: "IRAN"},
+		{List: "local", EntryID: "2", Name: "Example Trading Company"},
+	}, entries)
+
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent+"1,Duplicate,,,\n"), 0644))
+	_, err = parser.ParseSanctionCSV(tempFile, "local")
+	assert.ErrorContains(t, err, "duplicate ID")
+
+	assert.NoError(t, os.WriteFile(tempFile, []byte(csvContent+"3,Wrong Country,,Iran,\n"), 0644))
+	_, err = parser.ParseSanctionCSV(tempFile, "local")
+	assert.ErrorContains(t, err, "line 5")
+
+	assert.NoError(t, os.WriteFile(tempFile, []byte("NAME,PROGRAM\nBank,IRAN\n"), 0644))
+	_, err = parser.ParseSanctionCSV(tempFile, "local")
+	assert.ErrorContains(t, err, "missing column ID")
+}
+
+func TestParseSanctionXML(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "eu.xml")
+	xmlContent := `<?xml version="1.0" encoding="UTF-8"?>
+<export xmlns="http://eu.europa.ec/fpi/fsd/export" generationDate="2026-10-01T10:00:00.000+02:00">
+  <sanctionEntity logicalId="13" euReferenceNumber="EU.1.1">
+    <regulation programme="IRN"/>
+    <subjectType code="enterprise" classificationCode="E"/>
+    <nameAlias wholeName="Bank Melli Iran"/>
+    <nameAlias wholeName="Melli Bank"/>
+    <nameAlias wholeName="Bank Melli Iran"/>
+    <address countryIso2Code="IR"/>
+    <address countryIso2Code="DE"/>
+    <address countryIso2Code="00"/>
+  </sanctionEntity>
+  <sanctionEntity logicalId="14">
+    <subjectType code="person" classificationCode="P"/>
+    <nameAlias wholeName="John Example"/>
+  </sanctionEntity>
+</export>`
+	assert.NoError(t, os.WriteFile(tempFile, []byte(xmlContent), 0644))
+
+	entries, err := parser.ParseSanctionXML(tempFile, "eu")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.SanctionEntry{
+		{List: "eu", EntryID: "13", Name: "Bank Melli Iran", Aliases: []string{"Melli Bank"}, Countries: []string{"IR", "DE"}, Program: "IRN"},
+	}, entries, "persons are skipped")
+
+	assert.NoError(t, os.WriteFile(tempFile, []byte(`<export></export>`), 0644))
+	_, err = parser.ParseSanctionXML(tempFile, "eu")
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// ParseSanctionFile reads a sanctions list file as the entries of list, an XML file is
+// an EU consolidated list export, anything else a CSV file
+func ParseSanctionFile(path, list string) ([]model.SanctionEntry, error) {
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return ParseSanctionXML(path, list)
+	}
+	return ParseSanctionCSV(path, list)
+}
+
+// ParseSanctionCSV reads a sanctions list with a header naming its columns: ID and
+// NAME are required, ALIASES and COUNTRIES (ISO2 codes) are separated by semicolons,
+// PROGRAM is optional. Lines starting with # are comments.
+func ParseSanctionCSV(path, list string) ([]model.SanctionEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"ID", "NAME"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %s", required)
+		}
+	}
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var result []model.SanctionEntry
+	ids := map[string]bool{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		e := model.SanctionEntry{
+			List:    list,
+			EntryID: field(record, "ID"),
+			Name:    field(record, "NAME"),
+			Aliases: splitList(field(record, "ALIASES")),
+			Program: field(record, "PROGRAM"),
+		}
+		if e.EntryID == "" || e.Name == "" {
+			return nil, fmt.Errorf("line %d: ID and NAME are required", line)
+		}
+		if ids[e.EntryID] {
+			return nil, fmt.Errorf("line %d: duplicate ID %q", line, e.EntryID)
+		}
+		ids[e.EntryID] = true
+
+		for _, c := range splitList(field(record, "COUNTRIES")) {
+			c = strings.ToUpper(c)
+			if !isCountryCode(c) {
+				return nil, fmt.Errorf("line %d: invalid country code %q", line, c)
+			}
+			e.Countries = append(e.Countries, c)
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// entity of the EU consolidated financial sanctions list
+type fsfEntity struct {
+	LogicalID   string `xml:"logicalId,attr"`
+	SubjectType struct {
+		Code string `xml:"code,attr"`
+	} `xml:"subjectType"`
+	Regulations []struct {
+		Programme string `xml:"programme,attr"`
+	} `xml:"regulation"`
+	Names []struct {
+		WholeName string `xml:"wholeName,attr"`
+	} `xml:"nameAlias"`
+	Addresses []fsfCountry `xml:"address"`
+	Citizens  []fsfCountry `xml:"citizenship"`
+}
+
+type fsfCountry struct {
+	Code string `xml:"countryIso2Code,attr"`
+}
+
+// ParseSanctionXML reads the XML export of the EU consolidated financial sanctions
+// list. Persons are skipped, the first name of an entity is its name and the others
+// are aliases, its countries are those of its addresses and citizenships.
+func ParseSanctionXML(path, list string) ([]model.SanctionEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// the export is large, entities are decoded one at a time
+	decoder := xml.NewDecoder(file)
+	var result []model.SanctionEntry
+	found := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sanctionEntity" {
+			continue
+		}
+		found = true
+
+		var entity fsfEntity
+		if err := decoder.DecodeElement(&entity, &start); err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(entity.SubjectType.Code, "person") || entity.LogicalID == "" {
+			continue
+		}
+
+		var names []string
+		for _, n := range entity.Names {
+			if n.WholeName = strings.TrimSpace(n.WholeName); n.WholeName != "" && !contains(names, n.WholeName) {
+				names = append(names, n.WholeName)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		e := model.SanctionEntry{List: list, EntryID: entity.LogicalID, Name: names[0], Aliases: names[1:]}
+		for _, r := range entity.Regulations {
+			if r.Programme != "" {
+				e.Program = r.Programme
+				break
+			}
+		}
+		for _, c := range append(entity.Addresses, entity.Citizens...) {
+			code := strings.ToUpper(c.Code)
+			// unknown countries are 00
+			if isCountryCode(code) && !contains(e.Countries, code) {
+				e.Countries = append(e.Countries, code)
+			}
+		}
+		result = append(result, e)
+	}
+	if !found {
+		return nil, errors.New("no sanctionEntity elements, not an EU consolidated list export")
+	}
+	return result, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
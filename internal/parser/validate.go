@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Problem is a row that would be imported wrongly or not at all
+type Problem struct {
+	Line      int    `json:"line"`
+	SwiftCode string `json:"swiftCode,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Report summarizes a dry run of a SWIFT codes CSV file
+type Report struct {
+	Rows           int       `json:"rows"`
+	Valid          int       `json:"valid"`
+	Headquarters   int       `json:"headquarters"`
+	Branches       int       `json:"branches"`
+	Countries      int       `json:"countries"`
+	OrphanBranches []string  `json:"orphanBranches"` // branches whose headquarter is not in the file
+	Problems       []Problem `json:"problems"`
+}
+
+// ValidateSwiftCSV checks every row of the file without touching the database,
+// unlike ParseSwiftCSV it does not stop at the first malformed row
+func ValidateSwiftCSV(path string) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	// header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{OrphanBranches: []string{}, Problems: []Problem{}}
+	seen := make(map[string]int)
+	countries := make(map[string]bool)
+	var branches []string
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		report.Rows++
+
+		if err != nil {
+			report.Problems = append(report.Problems, Problem{Line: line, Message: err.Error()})
+			continue
+		}
+		if len(record) < 7 {
+			report.Problems = append(report.Problems, Problem{Line: line, Message: fmt.Sprintf("expected 7 columns, got %d", len(record))})
+			continue
+		}
+
+		countryCode := strings.ToUpper(record[0])
+		swiftCode := record[1]
+		problem := func(msg string) {
+			report.Problems = append(report.Problems, Problem{Line: line, SwiftCode: swiftCode, Message: msg})
+		}
+
+		valid := true
+		switch {
+		case !isSwiftCode(swiftCode):
+			problem("SWIFT code must be 11 upper case letters or digits")
+			valid = false
+		case len(countryCode) == 2 && swiftCode[4:6] != countryCode:
+			problem(fmt.Sprintf("SWIFT code country %s does not match %s", swiftCode[4:6], countryCode))
+			valid = false
+		}
+		if len(countryCode) != 2 {
+			problem(fmt.Sprintf("invalid country code %q", record[0]))
+			valid = false
+		}
+		if strings.TrimSpace(record[3]) == "" {
+			problem("bank name is empty")
+			valid = false
+		}
+		if strings.TrimSpace(record[6]) == "" {
+			problem("country name is empty")
+			valid = false
+		}
+		if first, ok := seen[swiftCode]; ok && swiftCode != "" {
+			problem(fmt.Sprintf("duplicate of line %d", first))
+			valid = false
+		} else {
+			seen[swiftCode] = line
+		}
+
+		if !valid {
+			continue
+		}
+		report.Valid++
+		countries[countryCode] = true
+		if swiftCode[8:] == "XXX" {
+			report.Headquarters++
+		} else {
+			report.Branches++
+			branches = append(branches, swiftCode)
+		}
+	}
+
+	for _, code := range branches {
+		if _, ok := seen[code[:8]+"XXX"]; !ok {
+			report.OrphanBranches = append(report.OrphanBranches, code)
+		}
+	}
+	report.Countries = len(countries)
+	return report, nil
+}
+
+func isSwiftCode(s string) bool {
+	if len(s) != 11 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,248 @@
+package quality
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// DefaultSamples is the number of sample violations reported per check
+const DefaultSamples = 5
+
+// Check finds one kind of problem in the directory. Fix is nil when the problem
+// needs a person to decide, otherwise it returns the corrected bank.
+type Check struct {
+	Name        string
+	Description string
+	Run         func(banks []model.Bank) []Violation
+	Fix         func(b model.Bank) (model.Bank, bool)
+}
+
+// Violation is a bank that fails a check
+type Violation struct {
+	SwiftCode string `json:"swiftCode"`
+	Message   string `json:"message"`
+}
+
+// Checks are run in this order, a new check only has to be added here
+var Checks = []Check{
+	{
+		Name:        "orphan-branches",
+		Description: "branches whose headquarter (XXX) is not in the directory",
+		Run:         orphanBranches,
+	},
+	{
+		Name:        "country-mismatch",
+		Description: "SWIFT codes whose country segment (characters 5-6) differs from the country code",
+		Run:         countryMismatch,
+	},
+	{
+		Name:        "duplicate-names",
+		Description: "headquarters of one country with the same name and different addresses",
+		Run:         duplicateNames,
+	},
+	{
+		Name:        "whitespace",
+		Description: "names and addresses with leading, trailing or repeated whitespace",
+		Run:         whitespace,
+		Fix:         fixWhitespace,
+	},
+}
+
+// LookupChecks returns the named checks, all of them without names
+func LookupChecks(names ...string) ([]Check, error) {
+	if len(names) == 0 {
+		return Checks, nil
+	}
+	var checks []Check
+	for _, name := range names {
+		i := slices.IndexFunc(Checks, func(c Check) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		checks = append(checks, Checks[i])
+	}
+	return checks, nil
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Check       string      `json:"check"`
+	Description string      `json:"description"`
+	Fixable     bool        `json:"fixable"`
+	Count       int         `json:"count"`
+	Samples     []Violation `json:"samples"` // the first violations by SWIFT code
+}
+
+// Report is the outcome of a quality run over the directory
+type Report struct {
+	Banks  int      `json:"banks"`
+	Checks []Result `json:"checks"`
+	Fixed  int      `json:"fixed,omitempty"` // banks corrected by a fix run
+}
+
+// Run runs the checks over banks and keeps up to samples violations of each
+func Run(banks []model.Bank, checks []Check, samples int) *Report {
+	banks = sortedBanks(banks)
+	report := &Report{Banks: len(banks), Checks: []Result{}}
+	for _, c := range checks {
+		violations := c.Run(banks)
+		sort.SliceStable(violations, func(i, j int) bool { return violations[i].SwiftCode < violations[j].SwiftCode })
+
+		r := Result{Check: c.Name, Description: c.Description, Fixable: c.Fix != nil, Count: len(violations), Samples: violations}
+		if len(r.Samples) > samples {
+			r.Samples = r.Samples[:samples]
+		}
+		if r.Samples == nil {
+			r.Samples = []Violation{}
+		}
+		report.Checks = append(report.Checks, r)
+	}
+	return report
+}
+
+// Fixes returns the banks changed by the fixable checks, each with every fix applied
+func Fixes(banks []model.Bank, checks []Check) []model.Bank {
+	var fixed []model.Bank
+	for _, b := range sortedBanks(banks) {
+		changed := false
+		for _, c := range checks {
+			if c.Fix == nil {
+				continue
+			}
+			if f, ok := c.Fix(b); ok {
+				b, changed = f, true
+			}
+		}
+		if changed {
+			fixed = append(fixed, b)
+		}
+	}
+	return fixed
+}
+
+func sortedBanks(banks []model.Bank) []model.Bank {
+	sorted := append([]model.Bank(nil), banks...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SwiftCode < sorted[j].SwiftCode })
+	return sorted
+}
+
+func orphanBranches(banks []model.Bank) []Violation {
+	codes := make(map[string]bool, len(banks))
+	for _, b := range banks {
+		codes[b.SwiftCode] = true
+	}
+
+	var violations []Violation
+	for _, b := range banks {
+		if len(b.SwiftCode) != 11 || strings.HasSuffix(b.SwiftCode, "XXX") {
+			continue
+		}
+		hq := b.SwiftCode[:8] + "XXX"
+		if !codes[hq] {
+			violations = append(violations, Violation{SwiftCode: b.SwiftCode, Message: "headquarter " + hq + " is missing"})
+		}
+	}
+	return violations
+}
+
+func countryMismatch(banks []model.Bank) []Violation {
+	var violations []Violation
+	for _, b := range banks {
+		if len(b.SwiftCode) < 6 {
+			continue
+		}
+		if segment := b.SwiftCode[4:6]; !strings.EqualFold(segment, b.CountryCode) {
+			violations = append(violations, Violation{
+				SwiftCode: b.SwiftCode,
+				Message:   fmt.Sprintf("code country %s, stored country %s", segment, b.CountryCode),
+			})
+		}
+	}
+	return violations
+}
+
+func duplicateNames(banks []model.Bank) []Violation {
+	// country and name -> headquarters, in SWIFT code order
+	groups := map[string][]model.Bank{}
+	var keys []string
+	for _, b := range banks {
+		if !b.IsHeadquarter {
+			continue
+		}
+		key := strings.ToUpper(b.CountryCode) + "\x00" + normalize(b.Name)
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], b)
+	}
+
+	var violations []Violation
+	for _, key := range keys {
+		group := groups[key]
+		first := group[0]
+		for _, b := range group[1:] {
+			if normalize(b.Address) != normalize(first.Address) {
+				violations = append(violations, Violation{
+					SwiftCode: b.SwiftCode,
+					Message:   fmt.Sprintf("same name as %s, different address", first.SwiftCode),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// upper case with single spaces, for comparisons
+func normalize(s string) string {
+	return strings.ToUpper(collapse(s))
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type textField struct {
+	name  string
+	value *string
+}
+
+// free text fields of a bank, by their JSON names
+func textFields(b *model.Bank) []textField {
+	return []textField{
+		{"bankName", &b.Name},
+		{"address", &b.Address},
+		{"townName", &b.TownName},
+		{"countryName", &b.CountryName},
+	}
+}
+
+func whitespace(banks []model.Bank) []Violation {
+	var violations []Violation
+	for _, b := range banks {
+		var fields []string
+		for _, f := range textFields(&b) {
+			if *f.value != collapse(*f.value) {
+				fields = append(fields, f.name)
+			}
+		}
+		if len(fields) > 0 {
+			violations = append(violations, Violation{SwiftCode: b.SwiftCode, Message: "extra whitespace in " + strings.Join(fields, ", ")})
+		}
+	}
+	return violations
+}
+
+func fixWhitespace(b model.Bank) (model.Bank, bool) {
+	changed := false
+	for _, f := range textFields(&b) {
+		if c := collapse(*f.value); c != *f.value {
+			*f.value = c
+			changed = true
+		}
+	}
+	return b, changed
+}
@@ -0,0 +1,79 @@
+package quality_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/quality"
+)
+
+var banks = []model.Bank{
+	{SwiftCode: "AAAAPLPWXXX", Name: "ALPHA BANK", Address: "MAIN ST 1  WARSAW", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true},
+	{SwiftCode: "AAAAPLPW001", Name: "ALPHA BANK", Address: "SIDE ST 2 WARSAW", CountryCode: "PL", CountryName: "POLAND"},
+	{SwiftCode: "BBBBPLPW001", Name: "BETA BANK", Address: "OTHER ST 3 KRAKOW", CountryCode: "PL", CountryName: "POLAND"},
+	{SwiftCode: "CCCCDEFFXXX", Name: "Alpha  Bank ", Address: "KAISERSTR 1 FRANKFURT", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true},
+	{SwiftCode: "DDDDPLPWXXX", Name: "alpha bank", Address: "main st 1 warsaw", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true},
+	{SwiftCode: "EEEEPLPWXXX", Name: "ALPHA BANK", Address: "ELSEWHERE 9", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true},
+}
+
+func TestRun(t *testing.T) {
+	report := quality.Run(banks, quality.Checks, quality.DefaultSamples)
+	assert.Equal(t, 6, report.Banks)
+
+	results := map[string]quality.Result{}
+	for _, r := range report.Checks {
+		results[r.Check] = r
+	}
+	assert.Len(t, results, len(quality.Checks))
+
+	assert.Equal(t, []quality.Violation{{SwiftCode: "BBBBPLPW001", Message: "headquarter BBBBPLPWXXX is missing"}}, results["orphan-branches"].Samples)
+
+	assert.Equal(t, []quality.Violation{{SwiftCode: "CCCCDEFFXXX", Message: "code country DE, stored country PL"}}, results["country-mismatch"].Samples)
+
+	// branches share the name of their headquarter, case and spaces do not count
+	assert.Equal(t, []quality.Violation{
+		{SwiftCode: "CCCCDEFFXXX", Message: "same name as AAAAPLPWXXX, different address"},
+		{SwiftCode: "EEEEPLPWXXX", Message: "same name as AAAAPLPWXXX, different address"},
+	}, results["duplicate-names"].Samples)
+
+	whitespace := results["whitespace"]
+	assert.True(t, whitespace.Fixable)
+	assert.False(t, results["orphan-branches"].Fixable)
+	assert.Equal(t, 2, whitespace.Count)
+	assert.Equal(t, "extra whitespace in address", whitespace.Samples[0].Message)
+	assert.Equal(t, "extra whitespace in bankName", whitespace.Samples[1].Message)
+
+	limited := quality.Run(banks, quality.Checks, 1)
+	for _, r := range limited.Checks {
+		assert.LessOrEqual(t, len(r.Samples), 1)
+	}
+	assert.Equal(t, 2, limited.Checks[2].Count, "counts are not limited")
+}
+
+func TestLookupChecks(t *testing.T) {
+	checks, err := quality.LookupChecks()
+	assert.NoError(t, err)
+	assert.Equal(t, len(quality.Checks), len(checks))
+
+	checks, err = quality.LookupChecks("whitespace", "orphan-branches")
+	assert.NoError(t, err)
+	if assert.Len(t, checks, 2) {
+		assert.Equal(t, "whitespace", checks[0].Name)
+	}
+
+	_, err = quality.LookupChecks("spelling")
+	assert.ErrorContains(t, err, "spelling")
+}
+
+func TestFixes(t *testing.T) {
+	fixed := quality.Fixes(banks, quality.Checks)
+	if assert.Len(t, fixed, 2) {
+		assert.Equal(t, "MAIN ST 1 WARSAW", fixed[0].Address)
+		assert.Equal(t, "Alpha Bank", fixed[1].Name)
+	}
+	assert.Equal(t, "MAIN ST 1  WARSAW", banks[0].Address, "the input is not changed")
+
+	checks, _ := quality.LookupChecks("orphan-branches")
+	assert.Empty(t, quality.Fixes(banks, checks), "only fixable checks change banks")
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/logging"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Policy is the limit applied to a single route (or route group).
+// Clients sending one of Keys are limited by PerKey, everyone else by PerIP.
+type Policy struct {
+	Name   string
+	PerKey Limit
+	PerIP  Limit
+	Keys   *auth.Keys // issued API keys, unknown keys are limited like clients without one
+}
+
+// Middleware enforces the policy using the given store
+func Middleware(store Store, policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, limit := clientKey(c, policy)
+		if limit.Unlimited() {
+			c.Next()
+			return
+		}
+
+		res, err := store.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			// do not block traffic when the shared store is down
+			logging.FromContext(c.Request.Context()).Error("Rate limit store error", "policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		setHeaders(c, res, limit)
+
+		if !res.Allowed {
+			reject(c, res)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+const meterKey = "ratelimit.meter"
+
+type meter struct {
+	store  Store
+	policy Policy
+}
+
+// Metered lets the handler charge the policy per item of a request with Charge,
+// e.g. per code of a batch lookup. The middleware itself charges nothing.
+func Metered(store Store, policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(meterKey, meter{store: store, policy: policy})
+		c.Next()
+	}
+}
+
+// Charge takes n requests of the daily quota (and one token of the bucket) of the
+// policy set by Metered. When it returns false the 429 response has been written.
+// Without Metered nothing is charged.
+func Charge(c *gin.Context, n int) bool {
+	value, ok := c.Get(meterKey)
+	if !ok || n <= 0 {
+		return true
+	}
+	m := value.(meter)
+	key, limit := clientKey(c, m.policy)
+	if limit.Unlimited() {
+		return true
+	}
+
+	res, err := m.store.TakeN(c.Request.Context(), key, limit, n)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Rate limit store error", "policy", m.policy.Name, "error", err)
+		return true
+	}
+	setHeaders(c, res, limit)
+	if !res.Allowed {
+		reject(c, res)
+		return false
+	}
+	return true
+}
+
+func reject(c *gin.Context, res Result) {
+	c.Header("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+	message := "Rate limit exceeded"
+	if res.QuotaExceeded {
+		message = "Daily quota exceeded"
+	}
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+}
+
+func clientKey(c *gin.Context, policy Policy) (string, Limit) {
+	return policy.Key(c.GetHeader(APIKeyHeader), c.ClientIP())
+}
+
+// Key returns the store key and the limit of a client, clients sending an issued API
+// key are identified by its holder, everyone else by IP. Made up keys would otherwise
+// get a fresh bucket each.
+func (p Policy) Key(apiKey, ip string) (string, Limit) {
+	if name, ok := p.Keys.Lookup(apiKey); ok {
+		return p.Name + ":key:" + name, p.PerKey
+	}
+	return p.Name + ":ip:" + ip, p.PerIP
+}
+
+func setHeaders(c *gin.Context, res Result, limit Limit) {
+	if limit.Rate > 0 {
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	}
+	if limit.DailyQuota > 0 {
+		c.Header("X-Quota-Limit", strconv.Itoa(limit.DailyQuota))
+		c.Header("X-Quota-Remaining", strconv.Itoa(res.QuotaRemaining))
+	}
+}
+
+// round up so clients never retry too early
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: Rate tokens are added every second up to Burst.
+// DailyQuota caps the number of requests per UTC day (0 = no quota).
+type Limit struct {
+	Rate       float64
+	Burst      int
+	DailyQuota int
+}
+
+// Unlimited reports whether the limit does not restrict anything
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 && l.DailyQuota <= 0
+}
+
+// Result of a single Take call
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // tokens left in the bucket
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // how long to wait before the next request is allowed
+
+	QuotaExceeded  bool
+	QuotaRemaining int // -1 when there is no daily quota
+}
+
+// Store keeps bucket state. MemoryStore is enough for a single instance,
+// a shared implementation (e.g. Redis) can be plugged in for multiple replicas.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// TakeN is Take for a request that counts as n against the daily quota,
+	// e.g. a batch of n lookups. The bucket is charged one token.
+	TakeN(ctx context.Context, key string, limit Limit, n int) (Result, error)
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	day      string // UTC day of the quota counter
+	dayCount int
+}
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+
+	// idle buckets are removed every sweepEvery calls
+	calls      int
+	sweepEvery int
+	idleTTL    time.Duration
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:    make(map[string]*bucket),
+		now:        time.Now,
+		sweepEvery: 10000,
+		idleTTL:    48 * time.Hour,
+	}
+}
+
+// SetClock replaces the time source (used in tests)
+func (s *MemoryStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.TakeN(ctx, key, limit, 1)
+}
+
+func (s *MemoryStore) TakeN(_ context.Context, key string, limit Limit, n int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.calls++
+	if s.calls%s.sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	res := Result{Limit: limit.Burst, QuotaRemaining: -1}
+
+	// refill
+	if limit.Rate > 0 {
+		elapsed := now.Sub(b.last).Seconds()
+		if elapsed > 0 {
+			b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		}
+	}
+	b.last = now
+
+	// daily quota
+	day := now.UTC().Format("2006-01-02")
+	if b.day != day {
+		b.day = day
+		b.dayCount = 0
+	}
+	if limit.DailyQuota > 0 && b.dayCount+n > limit.DailyQuota {
+		res.QuotaExceeded = true
+		res.QuotaRemaining = max(limit.DailyQuota-b.dayCount, 0)
+		res.RetryAfter = untilNextDay(now)
+		res.Remaining = int(b.tokens)
+		res.Reset = s.resetIn(b, limit)
+		return res, nil
+	}
+
+	if limit.Rate > 0 && b.tokens < 1 {
+		res.RetryAfter = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		res.Remaining = 0
+		res.Reset = s.resetIn(b, limit)
+		if limit.DailyQuota > 0 {
+			res.QuotaRemaining = limit.DailyQuota - b.dayCount
+		}
+		return res, nil
+	}
+
+	if limit.Rate > 0 {
+		b.tokens--
+	}
+	b.dayCount += n
+
+	res.Allowed = true
+	res.Remaining = int(b.tokens)
+	res.Reset = s.resetIn(b, limit)
+	if limit.DailyQuota > 0 {
+		res.QuotaRemaining = limit.DailyQuota - b.dayCount
+	}
+	return res, nil
+}
+
+func (s *MemoryStore) resetIn(b *bucket, limit Limit) time.Duration {
+	if limit.Rate <= 0 {
+		return 0
+	}
+	missing := float64(limit.Burst) - b.tokens
+	return time.Duration(missing / limit.Rate * float64(time.Second))
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > s.idleTTL {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func untilNextDay(now time.Time) time.Duration {
+	utc := now.UTC()
+	next := time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC)
+	return next.Sub(utc)
+}
+
+// ParseLimit reads a limit spec like "rate=10,burst=20,quota=5000".
+// An empty spec returns def.
+func ParseLimit(spec string, def Limit) (Limit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return def, nil
+	}
+
+	l := Limit{}
+	for _, part := range strings.Split(spec, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return def, fmt.Errorf("invalid rate limit option %q", part)
+		}
+		key, value := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+		switch key {
+		case "rate":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f < 0 {
+				return def, fmt.Errorf("invalid rate %q", value)
+			}
+			l.Rate = f
+		case "burst":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return def, fmt.Errorf("invalid burst %q", value)
+			}
+			l.Burst = n
+		case "quota":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return def, fmt.Errorf("invalid quota %q", value)
+			}
+			l.DailyQuota = n
+		default:
+			return def, fmt.Errorf("unknown rate limit option %q", key)
+		}
+	}
+
+	// a bucket must hold at least one token
+	if l.Rate > 0 && l.Burst == 0 {
+		l.Burst = int(math.Max(1, math.Ceil(l.Rate)))
+	}
+	return l, nil
+}
@@ -0,0 +1,215 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/ratelimit"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	limit := ratelimit.Limit{Rate: 1, Burst: 2}
+
+	// burst is available immediately
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(context.Background(), "client", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := store.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed, "Bucket should be empty after burst")
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	// one token is added after a second
+	now = now.Add(time.Second)
+	res, _ = store.Take(context.Background(), "client", limit)
+	assert.True(t, res.Allowed)
+
+	// other keys have their own bucket
+	res, _ = store.Take(context.Background(), "other", limit)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_DailyQuota(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	now := time.Date(2025, 3, 1, 23, 59, 0, 0, time.UTC)
+	store.SetClock(func() time.Time { return now })
+
+	limit := ratelimit.Limit{DailyQuota: 2}
+
+	for i := 0; i < 2; i++ {
+		res, _ := store.Take(context.Background(), "client", limit)
+		assert.True(t, res.Allowed)
+	}
+
+	res, _ := store.Take(context.Background(), "client", limit)
+	assert.False(t, res.Allowed)
+	assert.True(t, res.QuotaExceeded)
+	assert.Equal(t, time.Minute, res.RetryAfter, "Quota should reset at midnight UTC")
+
+	// quota resets on the next day
+	now = now.Add(time.Minute)
+	res, _ = store.Take(context.Background(), "client", limit)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_TakeN(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 1, Burst: 5, DailyQuota: 10}
+
+	res, _ := store.TakeN(context.Background(), "client", limit, 8)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 4, res.Remaining, "A batch takes one token")
+	assert.Equal(t, 2, res.QuotaRemaining)
+
+	// the whole batch has to fit into the quota
+	res, _ = store.TakeN(context.Background(), "client", limit, 3)
+	assert.False(t, res.Allowed)
+	assert.True(t, res.QuotaExceeded)
+	assert.Equal(t, 2, res.QuotaRemaining)
+
+	res, _ = store.TakeN(context.Background(), "client", limit, 2)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.QuotaRemaining)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("rate=2.5,burst=10,quota=100", ratelimit.Limit{})
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Rate: 2.5, Burst: 10, DailyQuota: 100}, limit)
+
+	def := ratelimit.Limit{Rate: 1, Burst: 1}
+	limit, err = ratelimit.ParseLimit("", def)
+	assert.NoError(t, err)
+	assert.Equal(t, def, limit)
+
+	limit, err = ratelimit.ParseLimit("rate=3", def)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, limit.Burst, "Burst should default to the rate")
+
+	_, err = ratelimit.ParseLimit("speed=3", def)
+	assert.Error(t, err)
+}
+
+var apiKeys, _ = auth.ParseKeys("team-a=team-a-key-0123456789,team-b=team-b-key-0123456789")
+
+func setupRouter(store ratelimit.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/v1/swift-codes/:swiftCode", ratelimit.Middleware(store, ratelimit.Policy{
+		Name:   "lookup",
+		PerIP:  ratelimit.Limit{Rate: 1, Burst: 1},
+		PerKey: ratelimit.Limit{Rate: 1, Burst: 3},
+		Keys:   apiKeys,
+	}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"swiftCode": c.Param("swiftCode")})
+	})
+	return router
+}
+
+func TestMiddleware_TooManyRequests(t *testing.T) {
+	router := setupRouter(ratelimit.NewMemoryStore())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_APIKeyScope(t *testing.T) {
+	router := setupRouter(ratelimit.NewMemoryStore())
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+		req.Header.Set(ratelimit.APIKeyHeader, "team-a-key-0123456789")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "API key clients should get the larger burst")
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+	req.Header.Set(ratelimit.APIKeyHeader, "team-a-key-0123456789")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// a different key is not affected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+	req.Header.Set(ratelimit.APIKeyHeader, "team-b-key-0123456789")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMiddleware_UnknownAPIKey(t *testing.T) {
+	router := setupRouter(ratelimit.NewMemoryStore())
+
+	// made up keys share the bucket of the IP instead of getting a fresh one each
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+		req.Header.Set(ratelimit.APIKeyHeader, "made-up-key-"+strconv.Itoa(i))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	policy := ratelimit.Policy{Name: "lookup", PerIP: ratelimit.Limit{DailyQuota: 5}}
+	router.POST("/lookup", ratelimit.Metered(ratelimit.NewMemoryStore(), policy), func(c *gin.Context) {
+		n, _ := strconv.Atoi(c.Query("n"))
+		if !ratelimit.Charge(c, n) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	// without Metered nothing is charged
+	router.POST("/free", func(c *gin.Context) {
+		if ratelimit.Charge(c, 1000) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	lookup := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := lookup("/lookup?n=4")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining"))
+
+	w = lookup("/lookup?n=2")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "Daily quota exceeded")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, lookup("/lookup?n=1").Code)
+	assert.Equal(t, http.StatusOK, lookup("/free").Code)
+}
@@ -0,0 +1,316 @@
+package screening
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/white67/swift_api/internal/model"
+)
+
+// DefaultThreshold is the lowest score reported as a match
+const DefaultThreshold = 0.88
+
+// legal forms and filler words that do not tell two institutions apart
+var ignoredWords = map[string]bool{
+	"AB": true, "AD": true, "AG": true, "AS": true, "ASA": true, "BV": true, "CJSC": true,
+	"CO": true, "CORP": true, "CORPORATION": true, "DD": true, "GMBH": true, "INC": true,
+	"JSC": true, "KG": true, "LIMITED": true, "LLC": true, "LTD": true, "NV": true, "OAO": true,
+	"OJSC": true, "OOO": true, "PAO": true, "PJSC": true, "PLC": true, "PSC": true, "SA": true,
+	"SAL": true, "SH": true, "SHA": true, "SPA": true, "ZAO": true,
+	"AND": true, "DE": true, "DEL": true, "DES": true, "DI": true, "DU": true, "LA": true,
+	"LE": true, "OF": true, "THE": true,
+}
+
+// words most bank names share, they count less than the distinctive part of a name
+var commonWords = map[string]bool{
+	"BANK": true, "BANCA": true, "BANCO": true, "BANQUE": true, "BANKA": true,
+	"COMMERCIAL": true, "CREDIT": true, "FINANCE": true, "FINANCIAL": true, "GROUP": true,
+	"HOLDING": true, "INTERNATIONAL": true, "INVESTMENT": true, "NATIONAL": true,
+	"SAVINGS": true, "TRUST": true,
+}
+
+const commonWordWeight = 0.3
+
+// letters folded to ASCII, names are compared in upper case
+var folds = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'Æ': "AE", 'Ç': "C", 'Ć': "C", 'Č': "C", 'Ď': "D", 'Đ': "D", 'Ð': "D",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'Ğ': "G", 'Ģ': "G", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ī': "I", 'Į': "I", 'İ': "I",
+	'Ķ': "K", 'Ĺ': "L", 'Ļ': "L", 'Ľ': "L", 'Ł': "L", 'Ñ': "N", 'Ń': "N", 'Ņ': "N", 'Ň': "N",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ő': "O", 'Œ': "OE",
+	'Ŕ': "R", 'Ř': "R", 'Ś': "S", 'Ş': "S", 'Š': "S", 'Ș': "S", 'ß': "SS",
+	'Ţ': "T", 'Ť': "T", 'Ț': "T", 'Þ': "TH",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ū': "U", 'Ů': "U", 'Ű': "U", 'Ų': "U",
+	'Ý': "Y", 'Ÿ': "Y", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+}
+
+// Normalize returns the words of a name in upper case ASCII without punctuation,
+// legal forms and filler words. A name made only of such words keeps them.
+func Normalize(name string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		switch {
+		case r == '.' || r == '\'' || r == '’':
+			// S.A., SH.A. and O'Neill are single words
+		case folds[r] != "":
+			b.WriteString(folds[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// letters of other scripts are compared as they are
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+
+	all := strings.Fields(b.String())
+	var words []string
+	for _, w := range all {
+		if !ignoredWords[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return all
+	}
+	return words
+}
+
+// Score returns the similarity of two names between 0 and 1, the better of comparing
+// them as a whole (edit distance) and word by word in any order (Jaro-Winkler)
+func Score(a, b string) float64 {
+	return score(Normalize(a), Normalize(b))
+}
+
+func score(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	// without spaces, DEUTSCHEBANK is DEUTSCHE BANK
+	whole := levenshteinRatio(strings.Join(a, ""), strings.Join(b, ""))
+	words := (wordScore(a, b) + wordScore(b, a)) / 2
+	return max(whole, words)
+}
+
+// weighted average of the best match of each word of a among the words of b
+func wordScore(a, b []string) float64 {
+	var sum, total float64
+	for _, wa := range a {
+		best := 0.0
+		for _, wb := range b {
+			best = max(best, jaroWinkler(wa, wb))
+		}
+		weight := 1.0
+		if commonWords[wa] {
+			weight = commonWordWeight
+		}
+		sum += best * weight
+		total += weight
+	}
+	return sum / total
+}
+
+// levenshteinRatio is 1 minus the edit distance relative to the longer string
+func levenshteinRatio(s1, s2 string) float64 {
+	a, b := []rune(s1), []rune(s2)
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range a {
+		cur[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(b)])/float64(max(len(a), len(b)))
+}
+
+// jaroWinkler is the Jaro similarity with a bonus for a common prefix of up to 4 letters
+func jaroWinkler(s1, s2 string) float64 {
+	a, b := []rune(s1), []rune(s2)
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if s1 == s2 {
+		return 1
+	}
+
+	window := max(len(a), len(b))/2 - 1
+	window = max(window, 0)
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Match is a sanctions list entry similar to a bank
+type Match struct {
+	Entry model.SanctionEntry
+	Name  string  // name or alias of the entry that matched best
+	Score float64 // between the threshold and 1
+}
+
+// Matcher screens bank names against the entries of sanctions lists
+type Matcher struct {
+	threshold float64
+	entries   []model.SanctionEntry
+	names     []name
+	index     map[string][]int // word prefix -> names
+}
+
+type name struct {
+	entry int
+	text  string
+	words []string
+}
+
+// length of the word prefixes candidates are looked up by, names have to share
+// the beginning of a word to be compared
+const prefixLen = 3
+
+// NewMatcher indexes the names and aliases of entries, a threshold outside (0, 1]
+// is DefaultThreshold
+func NewMatcher(entries []model.SanctionEntry, threshold float64) *Matcher {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultThreshold
+	}
+	m := &Matcher{threshold: threshold, entries: entries, index: map[string][]int{}}
+	for i, e := range entries {
+		for _, text := range append([]string{e.Name}, e.Aliases...) {
+			words := Normalize(text)
+			if len(words) == 0 {
+				continue
+			}
+			id := len(m.names)
+			m.names = append(m.names, name{entry: i, text: text, words: words})
+			for _, key := range prefixes(words) {
+				m.index[key] = append(m.index[key], id)
+			}
+		}
+	}
+	return m
+}
+
+// Len returns the number of indexed entries
+func (m *Matcher) Len() int {
+	return len(m.entries)
+}
+
+// Match returns the entries whose name or an alias scores at least the threshold
+// against the bank name, best first. An entry with countries only matches banks
+// in one of them.
+func (m *Matcher) Match(bankName, countryCode string) []Match {
+	words := Normalize(bankName)
+	best := map[int]Match{}
+	seen := map[int]bool{}
+	for _, key := range prefixes(words) {
+		for _, id := range m.index[key] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			n := m.names[id]
+			entry := m.entries[n.entry]
+			if !inCountries(entry.Countries, countryCode) {
+				continue
+			}
+			s := score(words, n.words)
+			if s < m.threshold || s <= best[n.entry].Score {
+				continue
+			}
+			best[n.entry] = Match{Entry: entry, Name: n.text, Score: math.Round(s*1000) / 1000}
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Entry.EntryID < matches[j].Entry.EntryID
+	})
+	return matches
+}
+
+// distinctive word prefixes of a name, all of them when every word is common
+func prefixes(words []string) []string {
+	var keys []string
+	for _, w := range words {
+		if !commonWords[w] {
+			keys = append(keys, prefix(w))
+		}
+	}
+	if len(keys) == 0 {
+		for _, w := range words {
+			keys = append(keys, prefix(w))
+		}
+	}
+	return keys
+}
+
+func prefix(word string) string {
+	r := []rune(word)
+	return string(r[:min(prefixLen, len(r))])
+}
+
+func inCountries(countries []string, countryCode string) bool {
+	if len(countries) == 0 {
+		return true
+	}
+	for _, c := range countries {
+		if strings.EqualFold(c, countryCode) {
+			return true
+		}
+	}
+	return false
+}
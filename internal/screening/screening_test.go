@@ -0,0 +1,61 @@
+package screening_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/screening"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, []string{"BANKA", "KOMBETARE", "TREGTARE"}, screening.Normalize("Banka Kombëtare Tregtare Sh.a."))
+	assert.Equal(t, []string{"BANCO", "ESPIRITO", "SANTO"}, screening.Normalize("Banco Espírito Santo, S.A."))
+	assert.Equal(t, []string{"PKO", "BANK", "POLSKI"}, screening.Normalize("PKO  Bank Polski S.A."))
+	assert.Equal(t, []string{"AG"}, screening.Normalize("A.G."), "a name of legal forms only is kept")
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 1.0, screening.Score("Bank Melli Iran", "BANK MELLI IRAN PJSC"))
+	assert.Equal(t, 1.0, screening.Score("Melli Bank Iran", "Bank Melli Iran"), "word order does not matter")
+	assert.Greater(t, screening.Score("Bank Meli Iran", "Bank Melli Iran"), 0.9, "a typo")
+	assert.Less(t, screening.Score("Bank Millennium", "Bank Melli Iran"), 0.88)
+	assert.Less(t, screening.Score("United Bank of Albania", "United Bank of Iran"), 0.88)
+	assert.Greater(t, screening.Score("Deutschebank", "Deutsche Bank AG"), 0.88)
+	assert.Equal(t, 0.0, screening.Score("", "Bank Melli Iran"))
+}
+
+func TestMatcher(t *testing.T) {
+	entries := []model.SanctionEntry{
+		{List: "test", EntryID: "1", Name: "Bank Melli Iran", Aliases: []string{"Melli Bank"}, Countries: []string{"IR", "DE"}},
+		{List: "test", EntryID: "2", Name: "Example Trading Company", Program: "TEST"},
+		{List: "test", EntryID: "3", Name: "Banca Popolare Exempla", Countries: []string{"IT"}},
+	}
+	m := screening.NewMatcher(entries, 0)
+	assert.Equal(t, 3, m.Len())
+
+	matches := m.Match("BANK MELLI IRAN", "DE")
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "1", matches[0].Entry.EntryID)
+		assert.Equal(t, "Bank Melli Iran", matches[0].Name)
+		assert.Equal(t, 1.0, matches[0].Score)
+	}
+
+	matches = m.Match("BANK MELLI IRAN HAMBURG BRANCH", "DE")
+	assert.Len(t, matches, 1, "a branch of a listed bank")
+
+	matches = m.Match("MELLI BANK AG", "DE")
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "Melli Bank", matches[0].Name, "aliases are matched too")
+	}
+
+	assert.Empty(t, m.Match("BANK MELLI IRAN", "PL"), "the entry is limited to other countries")
+	assert.Len(t, m.Match("EXAMPLE TRADING CO LTD", "PL"), 1, "an entry without countries matches anywhere")
+	assert.Empty(t, m.Match("BANCA POPOLARE EXEMPLA", "FR"))
+	assert.Len(t, m.Match("BANCA POPOLARE EXEMPLA S.P.A.", "it"), 1)
+	assert.Empty(t, m.Match("PKO BANK POLSKI", "PL"))
+
+	strict := screening.NewMatcher(entries, 0.99)
+	assert.Empty(t, strict.Match("BANK MELI IRAN", "DE"))
+	assert.Len(t, m.Match("BANK MELI IRAN", "DE"), 1)
+}
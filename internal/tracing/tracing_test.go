@@ -0,0 +1,108 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// records spans in memory instead of exporting them
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	_, err := tracing.Setup(context.Background(), tracing.Options{Exporter: "none"})
+	assert.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestMiddleware_Propagation(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/v1/swift-codes/:swiftCode", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "test", "database.GetBankBySwiftCode")
+		tracing.End(span, errors.New("connection refused"))
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/swift-codes/TESTPLPWXXX", nil)
+	req.Header.Set("traceparent", traceParent)
+	router.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "/v1/swift-codes/:swiftCode", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "Trace should continue from traceparent")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	assert.Equal(t, "database.GetBankBySwiftCode", child.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID(), "Database span should be a child of the request span")
+	assert.Equal(t, codes.Error, child.Status().Code)
+}
+
+func TestMiddleware_NoIBAN(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/v1/iban/:iban/validate", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/iban/PL61109010140000071219812874/validate", nil)
+	router.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	assert.Equal(t, "/v1/iban/:iban/validate", spans[0].Name())
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "PL61109010140000071219812874", string(attr.Key))
+	}
+}
+
+func TestSetup_StdoutFile(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: "stdout", File: path, SampleRatio: 1})
+	assert.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "test", "render")
+	tracing.End(span, nil)
+	assert.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"render"`)
+
+	_, err = tracing.Setup(context.Background(), tracing.Options{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/model"
+)
+
+// Options configures the Dispatcher, zero values use the defaults
+type Options struct {
+	Interval    time.Duration // poll interval for due deliveries (default 5s)
+	Timeout     time.Duration // per request (default 10s)
+	MaxAttempts int           // attempts before a delivery is marked failed (default 8)
+	Backoff     time.Duration // wait after the first failed attempt, doubled after each one (default 30s)
+	MaxBackoff  time.Duration // default 1h
+	BatchSize   int           // deliveries claimed per poll (default 50)
+	// deliver to loopback, link-local and private addresses, for receivers on the
+	// same host or network; by default those connections are refused
+	AllowPrivate bool
+	Client       *http.Client // used as is, default: Timeout, no redirects and the AllowPrivate check
+}
+
+// Dispatcher sends queued webhook deliveries and retries failed ones
+type Dispatcher struct {
+	db     *sql.DB
+	opts   Options
+	client *http.Client
+}
+
+func NewDispatcher(db *sql.DB, opts Options) *Dispatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	client := opts.Client
+	if client == nil {
+		client = newClient(opts.Timeout, opts.AllowPrivate)
+	}
+	return &Dispatcher{db: db, opts: opts, client: client}
+}
+
+// Run polls for due deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		// drain the queue before waiting for the next tick
+		for {
+			n, err := d.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("Webhook dispatch failed", "error", err)
+			}
+			if err != nil || n < d.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends one batch of due deliveries and returns how many were attempted
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	// a claimed delivery is retried by the next poll if this instance dies while sending it
+	lease := d.opts.Timeout + time.Minute
+	pending, err := database.ClaimWebhookDeliveriesContext(ctx, d.db, d.opts.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range pending {
+		attempt := d.send(ctx, p)
+		if ctx.Err() != nil {
+			// shutting down, the lease expires and the delivery is sent again
+			return len(pending), ctx.Err()
+		}
+
+		status, next := database.DeliveryDelivered, time.Now()
+		if attempt.Error != "" {
+			status = database.DeliveryPending
+			next = time.Now().Add(d.backoff(p.Attempts))
+			if p.Attempts+1 >= d.opts.MaxAttempts {
+				status = database.DeliveryFailed
+			}
+		}
+
+		logger := slog.With("delivery_id", p.ID, "subscription_id", p.SubscriptionID, "event_id", p.Event.ID)
+		if err := database.RecordWebhookAttemptContext(ctx, d.db, p.ID, attempt, status, next); err != nil {
+			logger.Error("Error when recording webhook attempt", "error", err)
+		}
+		switch status {
+		case database.DeliveryFailed:
+			logger.Warn("Webhook delivery failed permanently", "attempts", p.Attempts+1, "error", attempt.Error)
+		case database.DeliveryPending:
+			logger.Info("Webhook delivery failed, retrying", "attempt", p.Attempts+1, "next_attempt", next, "error", attempt.Error)
+		}
+	}
+	return len(pending), nil
+}
+
+// posts the event, any response other than 2xx is a failed attempt
+func (d *Dispatcher) send(ctx context.Context, p database.PendingDelivery) (attempt model.WebhookAttempt) {
+	start := time.Now()
+	attempt.AttemptedAt = start
+	defer func() { attempt.DurationMs = time.Since(start).Milliseconds() }()
+
+	body, err := json.Marshal(p.Event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "swift_api-webhooks")
+	req.Header.Set(EventHeader, p.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(p.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(p.Secret, start, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// exponential backoff with up to 50% jitter, attempts is the number of failed attempts before this one
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.opts.Backoff << attempts
+	if b <= 0 || b > d.opts.MaxBackoff {
+		b = d.opts.MaxBackoff
+	}
+	return b/2 + rand.N(b/2+1)
+}
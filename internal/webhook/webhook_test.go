@@ -0,0 +1,177 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/webhook"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":1,"type":"created"}`)
+	now := time.Unix(1700000000, 0)
+	header := webhook.Sign("secret", now, body)
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		valid  bool
+	}{
+		{"valid", "secret", header, body, now.Add(time.Minute), true},
+		{"rotated secret listed second", "secret", webhook.Sign("old", now, body) + "," + header[len("t=1700000000,"):], body, now, true},
+		{"wrong secret", "other", header, body, now, false},
+		{"modified body", "secret", header, []byte(`{"id":2,"type":"created"}`), now, false},
+		{"too old", "secret", header, body, now.Add(10 * time.Minute), false},
+		{"no timestamp", "secret", header[len("t=1700000000,"):], body, now, false},
+		{"garbage", "secret", "nope", body, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.Verify(tt.secret, tt.header, tt.body, tt.now, 5*time.Minute)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, webhook.ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://93.184.215.14/hooks", true},
+		{"http://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:8080/hooks", true},
+		{"ftp://93.184.215.14/hooks", false},
+		{"/hooks", false},
+		{"http://127.0.0.1:8080/hooks", false},
+		{"http://[::1]/hooks", false},
+		{"http://10.0.0.5/hooks", false},
+		{"http://192.168.1.1/hooks", false},
+		{"http://172.16.0.1/hooks", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[fe80::1]/hooks", false},
+		{"http://[::ffff:127.0.0.1]/hooks", false},
+		{"http://0.0.0.0/hooks", false},
+		{"http://100.64.0.1/hooks", false},
+	}
+	for _, tt := range tests {
+		err := webhook.CheckURL(context.Background(), tt.url, false)
+		if tt.valid {
+			assert.NoError(t, err, tt.url)
+		} else {
+			assert.Error(t, err, tt.url)
+		}
+	}
+
+	assert.ErrorIs(t, webhook.CheckURL(context.Background(), "http://10.0.0.5/hooks", false), webhook.ErrPrivateTarget)
+	assert.NoError(t, webhook.CheckURL(context.Background(), "http://127.0.0.1:8080/hooks", true))
+	assert.Error(t, webhook.CheckURL(context.Background(), "ftp://127.0.0.1/hooks", true))
+}
+
+// receiver that fails the first request and records every valid one
+type receiver struct {
+	mu     sync.Mutex
+	calls  int
+	events []model.Event
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if webhook.Verify("test-secret", req.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var e model.Event
+	json.Unmarshal(body, &e)
+	if req.Header.Get(webhook.EventHeader) == e.Type {
+		r.events = append(r.events, e)
+	}
+}
+
+func (r *receiver) received() []model.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.Event(nil), r.events...)
+}
+
+func TestDispatcher(t *testing.T) {
+	db := config.ConnectToDB()
+	config.InitSchema(db)
+	defer db.Close()
+
+	ctx := context.Background()
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	db.Exec("DELETE FROM banks WHERE swift_code LIKE 'HOOK%'")
+	defer db.Exec("DELETE FROM banks WHERE swift_code LIKE 'HOOK%'")
+
+	// only created and deleted, the update must not be delivered
+	sub := model.Webhook{URL: server.URL, Secret: "test-secret", EventTypes: []string{model.EventCreated, model.EventDeleted}}
+	assert.NoError(t, database.CreateWebhookContext(ctx, db, &sub))
+	defer database.DeleteWebhookContext(ctx, db, sub.ID)
+
+	bank := model.Bank{SwiftCode: "HOOKPLPWXXX", Name: "Hook Bank", Address: "Hook Street 1", CountryCode: "PL", CountryName: "POLAND", IsHeadquarter: true}
+	assert.NoError(t, database.CreateBankContext(ctx, db, bank))
+	bank.Address = "Hook Street 2"
+	_, err := database.UpdateBankContext(ctx, db, bank, time.Time{})
+	assert.NoError(t, err)
+	_, err = database.DeleteBankContext(ctx, db, bank.SwiftCode, time.Time{})
+	assert.NoError(t, err)
+
+	// the receiver listens on 127.0.0.1
+	dispatcher := webhook.NewDispatcher(db, webhook.Options{Backoff: time.Millisecond, MaxBackoff: time.Millisecond, AllowPrivate: true})
+	for i := 0; i < 50 && len(recv.received()) < 2; i++ {
+		_, err := dispatcher.RunOnce(ctx)
+		assert.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	events := recv.received()
+	if assert.Len(t, events, 2) {
+		types := []string{events[0].Type, events[1].Type}
+		assert.ElementsMatch(t, []string{model.EventCreated, model.EventDeleted}, types)
+		for _, e := range events {
+			assert.Equal(t, "HOOKPLPWXXX", e.SwiftCode)
+			assert.Equal(t, "PL", e.CountryCode)
+		}
+	}
+
+	// the failed first attempt is in the log
+	deliveries, err := database.ListWebhookDeliveriesContext(ctx, db, sub.ID, 10)
+	assert.NoError(t, err)
+	attempts := 0
+	for _, d := range deliveries {
+		assert.Equal(t, database.DeliveryDelivered, d.Status)
+		attempts += len(d.Attempts)
+	}
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,4 @@
+package swiftv1
+
+// regenerate after changing swift.proto (needs protoc, protoc-gen-go and protoc-gen-go-grpc)
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative swift/v1/swift.proto
@@ -1,59 +1,372 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-
-	// "os"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // bank time zones, the runtime image has no zoneinfo
 
 	"github.com/gin-gonic/gin"
-	// "github.com/joho/godotenv"
+	"github.com/white67/swift_api/internal/auth"
+	"github.com/white67/swift_api/internal/cache"
 	"github.com/white67/swift_api/internal/config"
 	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/geo"
+	"github.com/white67/swift_api/internal/grpcserver"
 	"github.com/white67/swift_api/internal/handler"
+	"github.com/white67/swift_api/internal/iban"
+	"github.com/white67/swift_api/internal/logging"
+	"github.com/white67/swift_api/internal/metrics"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/openapi"
 	"github.com/white67/swift_api/internal/parser"
+	"github.com/white67/swift_api/internal/ratelimit"
+	"github.com/white67/swift_api/internal/tracing"
+	"github.com/white67/swift_api/internal/webhook"
 )
 
 func main() {
+	// exits only here, so the deferred cleanups in run are not skipped
+	if err := run(os.Args[1:]); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
 
-	// load env variables
-	// if os.Getenv("ENV") != "production" {
-	// 	err := godotenv.Load("internal/config/.env")
-	// 	if err != nil {
-	// 		log.Fatal("Error loading .env file")
-	// 	}
-	// }
+func run(args []string) error {
+
+	// load configuration (defaults < config file < env < flags)
+	cfg, err := config.Load(args)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// structured logging for every package (log and slog defaults)
+	logger, err := logging.Setup(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return err
+	}
+	logger.Info("Effective configuration", "config", cfg.Redacted())
+
+	// tracing (spans are flushed on shutdown, also when starting fails)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("Flushing traces failed", "error", err)
+		}
+	}()
 
 	// connect to database
-	db := config.ConnectToDB()
+	db, err := config.Connect(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("database connection: %w", err)
+	}
 	defer db.Close()
 
-	config.InitSchema(db)
+	if err := config.Migrate(db); err != nil {
+		return fmt.Errorf("creating tables: %w", err)
+	}
+	logger.Info("Table has been created")
 
-	// check if database is empty
-	empty, err := database.IsDatabaseEmpty(db)
-	if err != nil {
-		log.Fatal("Error when checking if database is empty:", err)
+	if err := metrics.RegisterDB(db, "swiftdb"); err != nil {
+		return fmt.Errorf("registering database metrics: %w", err)
 	}
 
-	// parse data from .csv file to database if empty
-	if empty {
-		fmt.Println("Add new data from .csv file as database is empty")
-		banks, err := parser.ParseSwiftCSV("data/2025_SWIFT_CODES.csv")
+	// lookup cache (size 0 disables it)
+	if cfg.Cache.Size > 0 {
+		dirCache := cache.NewDirectory(cfg.Cache.Size, cfg.Cache.TTL)
+		handler.SetCache(dirCache)
+		if err := metrics.RegisterCache(dirCache); err != nil {
+			return fmt.Errorf("registering cache metrics: %w", err)
+		}
+	}
+
+	// town coordinates, needed before the seed import
+	if cfg.GazetteerFile != "" {
+		gazetteer, err := geo.LoadGazetteer(cfg.GazetteerFile)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("loading gazetteer: %w", err)
 		}
-		err = database.InsertAllBanks(db, banks)
+		database.SetGazetteer(gazetteer)
+		handler.SetGazetteer(gazetteer)
+		logger.Info("Gazetteer loaded", "towns", gazetteer.Len())
+	}
+
+	// offline IBAN validation
+	if cfg.IBAN.RegistryFile != "" {
+		registry, err := iban.LoadRegistry(cfg.IBAN.RegistryFile)
 		if err != nil {
-			log.Fatal("Error when inserting new items:", err)
+			return fmt.Errorf("loading IBAN registry: %w", err)
 		}
+		codes := 0
+		if cfg.IBAN.BankCodesFile != "" {
+			if codes, err = registry.LoadBankCodes(cfg.IBAN.BankCodesFile); err != nil {
+				return fmt.Errorf("loading IBAN bank codes: %w", err)
+			}
+		}
+		handler.SetIBANRegistry(registry)
+		logger.Info("IBAN registry loaded", "countries", registry.Countries(), "bank_codes", codes)
+	}
+
+	// business hours for /clock, holidays are read from the database
+	hours, err := cfg.BusinessHours.Hours()
+	if err != nil {
+		return fmt.Errorf("business hours: %w", err)
+	}
+	handler.SetBusinessHours(&hours)
+
+	// the holiday file only fills an empty holidays table, `swiftctl import-holidays` adds more
+	if cfg.BusinessHours.HolidaysFile != "" {
+		holidays, err := database.SeedHolidaysIfEmpty(context.Background(), db, cfg.BusinessHours.HolidaysFile, func(path string) ([]model.Holiday, error) {
+			return parser.ParseHolidayFile(path, "")
+		})
+		if err != nil {
+			return fmt.Errorf("seeding holidays: %w", err)
+		}
+		if holidays != nil {
+			logger.Info("Holidays imported", "file", cfg.BusinessHours.HolidaysFile, "holidays", len(holidays))
+		}
+	}
+
+	// rate limits (single code lookups are the easiest to scrape), only issued API keys
+	// get the key limits
+	apiKeys, err := auth.ParseKeys(cfg.RateLimit.APIKeys)
+	if err != nil {
+		return fmt.Errorf("API keys: %w", err)
+	}
+	limiter := ratelimit.NewMemoryStore()
+	lookupPolicy, err := newPolicy("lookup", cfg.RateLimit.LookupIP, cfg.RateLimit.LookupKey, apiKeys)
+	if err != nil {
+		return err
+	}
+	defaultPolicy, err := newPolicy("default", cfg.RateLimit.DefaultIP, cfg.RateLimit.DefaultKey, apiKeys)
+	if err != nil {
+		return err
+	}
+	exportPolicy, err := newPolicy("export", cfg.RateLimit.ExportIP, cfg.RateLimit.ExportKey, apiKeys)
+	if err != nil {
+		return err
+	}
+	lookupLimit := ratelimit.Middleware(limiter, lookupPolicy)
+	defaultLimit := ratelimit.Middleware(limiter, defaultPolicy)
+
+	// admin routes are rejected without keys
+	adminKeys, err := auth.ParseKeys(cfg.Admin.Keys)
+	if err != nil {
+		return fmt.Errorf("admin keys: %w", err)
 	}
+	if adminKeys.Len() == 0 {
+		logger.Warn("No admin keys configured, the admin routes are disabled (ADMIN_KEYS)")
+	}
+	handler.SetAllowPrivateWebhooks(cfg.Webhooks.AllowPrivateTargets)
+	handler.SetMaxEventStreams(cfg.Events.MaxStreamsPerClient)
+	handler.SetEventClientKeys(apiKeys)
 
 	// create gin router
-	router := gin.Default()
-	router.GET("/v1/swift-codes/:swiftCode", handler.GetSwiftCodeDetails)
-	router.GET("/v1/swift-codes/country/:countryISO2code", handler.GetCountryDetails)
-	router.POST("/v1/swift-codes", handler.AddSwiftCode)
-	router.DELETE("/v1/swift-codes/:swiftCode", handler.DeleteSwiftCode)
-	router.Run(":8080")
+	router := gin.New()
+	router.Use(gin.Recovery(), tracing.Middleware(), logging.Middleware(), metrics.Middleware())
+
+	routes := handler.RouteOptions{
+		LookupLimit:  lookupLimit,
+		LookupMeter:  ratelimit.Metered(limiter, lookupPolicy),
+		DefaultLimit: defaultLimit,
+		ExportLimit:  ratelimit.Middleware(limiter, exportPolicy),
+		Admin:        auth.Middleware(adminKeys),
+		SwaggerUI:    cfg.Server.SwaggerUI,
+	}
+	if cfg.Server.ValidateRequests {
+		routes.Validate, err = openapi.Validator()
+		if err != nil {
+			return fmt.Errorf("loading OpenAPI spec: %w", err)
+		}
+	}
+	if err := handler.RegisterRoutes(router, routes); err != nil {
+		return fmt.Errorf("registering routes: %w", err)
+	}
+
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	// event streams never finish by themselves
+	server.RegisterOnShutdown(handler.CloseEventStreams)
+
+	// stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 3)
+	go func() {
+		serverErr <- serve(server, cfg.Server)
+	}()
+
+	// gRPC on its own port, sharing the database, the cache and the rate limits with the REST API
+	grpcServer, grpcHealth := grpcserver.New(db, handler.GetCache(), grpcserver.Limits{
+		Store:   limiter,
+		Lookup:  lookupPolicy,
+		Export:  exportPolicy,
+		Default: defaultPolicy,
+	})
+	if cfg.Server.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.Server.GRPCAddr)
+		if err != nil {
+			return fmt.Errorf("gRPC listen: %w", err)
+		}
+		logger.Info("gRPC server listening", "addr", lis.Addr().String())
+		go func() {
+			serverErr <- grpcServer.Serve(lis)
+		}()
+	}
+
+	// parse data from .csv file to database if empty (same as `swiftctl seed`),
+	// the server is already up but not ready until the import is done. Stopped with ctx,
+	// an interrupted seed is resumed after a restart.
+	seedDone := make(chan struct{})
+	if cfg.SeedFile != "" {
+		handler.SetImporting(true)
+		go func() {
+			defer close(seedDone)
+			defer handler.SetImporting(false)
+
+			banks, err := database.SeedIfEmpty(ctx, db, cfg.SeedFile, parser.ParseSwiftCSV)
+			if err != nil {
+				if ctx.Err() == nil {
+					serverErr <- fmt.Errorf("seeding the database: %w", err)
+				}
+				return
+			}
+			handler.GetCache().InvalidateBanks(banks)
+			grpcserver.SetServing(grpcHealth, true)
+		}()
+	} else {
+		close(seedDone)
+		grpcserver.SetServing(grpcHealth, true)
+	}
+
+	// webhook deliveries, stopped with ctx; unsent ones are picked up after a restart
+	dispatcherDone := make(chan struct{})
+	if cfg.Webhooks.Interval > 0 {
+		dispatcher := webhook.NewDispatcher(db, webhook.Options{
+			Interval:     cfg.Webhooks.Interval,
+			Timeout:      cfg.Webhooks.Timeout,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			Backoff:      cfg.Webhooks.Backoff,
+			MaxBackoff:   cfg.Webhooks.MaxBackoff,
+			AllowPrivate: cfg.Webhooks.AllowPrivateTargets,
+		})
+		go func() {
+			defer close(dispatcherDone)
+			dispatcher.Run(ctx)
+		}()
+	} else {
+		close(dispatcherDone)
+	}
+
+	// events older than the retention are deleted, stopped with ctx
+	if cfg.Events.Retention > 0 {
+		go pruneEvents(ctx, db, cfg.Events.Retention)
+	}
+
+	// a failed server still shuts the others down before its error is returned
+	var serveErr error
+	select {
+	case serveErr = <-serverErr:
+	case <-ctx.Done():
+	}
+
+	// let in-flight requests finish, db.Close runs afterwards
+	logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Graceful shutdown failed", "error", err)
+	}
+	grpcHealth.Shutdown()
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	select {
+	case <-dispatcherDone:
+	case <-shutdownCtx.Done():
+	}
+	select {
+	case <-seedDone:
+	case <-shutdownCtx.Done():
+	}
+	return serveErr
+}
+
+func serve(server *http.Server, cfg config.ServerConfig) error {
+	var err error
+	if cfg.TLSCertFile != "" {
+		err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// deletes old events once an hour, events with pending webhook deliveries are kept
+func pruneEvents(ctx context.Context, db *sql.DB, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		deleted, err := database.DeleteEventsBeforeContext(ctx, db, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Deleting old events failed", "error", err)
+		} else if deleted > 0 {
+			slog.Info("Old events deleted", "events", deleted, "retention", retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rate limit policy from the per IP and per key limits, which config.Load validates already
+func newPolicy(name, perIP, perKey string, keys *auth.Keys) (ratelimit.Policy, error) {
+	policy := ratelimit.Policy{Name: name, Keys: keys}
+	var err error
+	if policy.PerIP, err = ratelimit.ParseLimit(perIP, ratelimit.Limit{}); err != nil {
+		return policy, fmt.Errorf("%s rate limit: %w", name, err)
+	}
+	if policy.PerKey, err = ratelimit.ParseLimit(perKey, ratelimit.Limit{}); err != nil {
+		return policy, fmt.Errorf("%s rate limit: %w", name, err)
+	}
+	return policy, nil
 }
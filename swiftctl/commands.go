@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/export"
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+	"github.com/white67/swift_api/internal/quality"
+	"github.com/white67/swift_api/internal/screening"
+)
+
+// same shape as GET /v1/swift-codes/{swift-code}
+type bankDetails struct {
+	model.Bank
+	Branches []model.Bank `json:"branches,omitempty"`
+}
+
+func runLookup(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageError("lookup: missing SWIFT code")
+	}
+
+	var found []bankDetails
+	missing := false
+	for _, arg := range args {
+		code := normalizeCode(arg)
+		bank, err := database.GetBankBySwiftCodeContext(a.ctx, a.db, code)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintf(a.stderr, "%s: not found\n", code)
+			missing = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		details := bankDetails{Bank: *bank}
+		if bank.IsHeadquarter {
+			details.Branches, err = database.GetBranchesForHeadquarterContext(a.ctx, a.db, code)
+			if err != nil {
+				return err
+			}
+		}
+		found = append(found, details)
+	}
+
+	a.out.details(found)
+	if missing {
+		return errSilent
+	}
+	return nil
+}
+
+func runCountry(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("country: expected one ISO2 country code")
+	}
+	country := normalizeCode(args[0])
+
+	banks, err := database.GetBanksByCountryContext(a.ctx, a.db, country)
+	if err != nil {
+		return err
+	}
+	if len(banks) == 0 {
+		return fmt.Errorf("no banks found for %s", country)
+	}
+	a.out.banks(banks)
+	return nil
+}
+
+func runSearch(a *app, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	country := fs.String("country", "", "only this ISO2 country")
+	limit := fs.Int("limit", 50, "maximum number of results")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError("search: missing search text")
+	}
+
+	banks, err := database.SearchBanksContext(a.ctx, a.db, strings.Join(fs.Args(), " "), *country, *limit)
+	if err != nil {
+		return err
+	}
+	a.out.banks(banks)
+	return nil
+}
+
+type importResult struct {
+	File     string `json:"file"`
+	Rows     int    `json:"rows"`
+	Failed   int    `json:"failed"`
+	Skipped  bool   `json:"skipped,omitempty"`
+	Duration string `json:"duration"`
+}
+
+func runImport(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("import: expected one CSV file")
+	}
+
+	start := time.Now()
+	banks, err := parser.ParseSwiftCSV(args[0])
+	if err != nil {
+		return err
+	}
+	failed, err := database.ImportBanks(a.ctx, a.db, banks)
+	if err != nil {
+		return err
+	}
+	a.out.importResult(importResult{File: args[0], Rows: len(banks), Failed: failed, Duration: time.Since(start).Round(time.Millisecond).String()})
+	if failed > 0 {
+		return errSilent
+	}
+	return nil
+}
+
+func runSeed(a *app, args []string) error {
+	if len(args) > 1 {
+		return usageError("seed: expected at most one CSV file")
+	}
+	file := a.cfg.SeedFile
+	if len(args) == 1 {
+		file = args[0]
+	}
+	if file == "" {
+		return usageError("seed: no CSV file given and SEED_FILE is not set")
+	}
+
+	start := time.Now()
+	banks, err := database.SeedIfEmpty(a.ctx, a.db, file, parser.ParseSwiftCSV)
+	if err != nil {
+		return err
+	}
+	a.out.importResult(importResult{File: file, Rows: len(banks), Skipped: banks == nil, Duration: time.Since(start).Round(time.Millisecond).String()})
+	return nil
+}
+
+type identifierImportResult struct {
+	File      string `json:"file"`
+	Rows      int    `json:"rows"`
+	Unmatched int    `json:"unmatched"` // SWIFT code not in the directory
+	Failed    int    `json:"failed"`
+	Duration  string `json:"duration"`
+}
+
+func runImportIdentifiers(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("import-identifiers: expected one CSV file")
+	}
+
+	start := time.Now()
+	ids, err := parser.ParseIdentifierCSV(args[0])
+	if err != nil {
+		return err
+	}
+	unmatched, failed, err := database.ImportIdentifiersContext(a.ctx, a.db, ids)
+	if err != nil {
+		return err
+	}
+	a.out.identifierImportResult(identifierImportResult{File: args[0], Rows: len(ids), Unmatched: unmatched, Failed: failed, Duration: time.Since(start).Round(time.Millisecond).String()})
+	if failed > 0 {
+		return errSilent
+	}
+	return nil
+}
+
+type holidayImportResult struct {
+	File     string `json:"file"`
+	Country  string `json:"country,omitempty"`
+	Holidays int    `json:"holidays"`
+	Duration string `json:"duration"`
+}
+
+func runImportHolidays(a *app, args []string) error {
+	fs := flag.NewFlagSet("import-holidays", flag.ContinueOnError)
+	country := fs.String("country", "", "country of an .ics file, only this country from a CSV file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("import-holidays: expected one CSV or .ics file")
+	}
+	file := fs.Arg(0)
+	if strings.EqualFold(filepath.Ext(file), ".ics") && *country == "" {
+		return usageError("import-holidays: .ics files need -country")
+	}
+
+	start := time.Now()
+	holidays, err := parser.ParseHolidayFile(file, *country)
+	if err != nil {
+		return err
+	}
+	if err := database.ImportHolidaysContext(a.ctx, a.db, holidays); err != nil {
+		return err
+	}
+	a.out.holidayImportResult(holidayImportResult{File: file, Country: strings.ToUpper(*country), Holidays: len(holidays), Duration: time.Since(start).Round(time.Millisecond).String()})
+	return nil
+}
+
+type screeningResult struct {
+	File     string `json:"file,omitempty"`
+	List     string `json:"list,omitempty"`
+	Entries  int    `json:"entries,omitempty"`
+	Removed  int64  `json:"removed,omitempty"` // entries no longer on the list
+	Banks    int    `json:"banks"`
+	Hits     int    `json:"hits"`
+	NewHits  int    `json:"newHits"`
+	Duration string `json:"duration"`
+}
+
+func runImportSanctions(a *app, args []string) error {
+	fs := flag.NewFlagSet("import-sanctions", flag.ContinueOnError)
+	list := fs.String("list", "", "name of the list, the file name without extension by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("import-sanctions: expected one CSV or XML file")
+	}
+	file := fs.Arg(0)
+	if *list == "" {
+		*list = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	start := time.Now()
+	entries, err := parser.ParseSanctionFile(file, *list)
+	if err != nil {
+		return err
+	}
+	removed, err := database.ImportSanctionListContext(a.ctx, a.db, *list, entries)
+	if err != nil {
+		return err
+	}
+	// every bank is screened again, the list has changed
+	screened, err := screenBanks(a, true)
+	if err != nil {
+		return err
+	}
+	a.out.screeningResult(screeningResult{
+		File:     file,
+		List:     *list,
+		Entries:  len(entries),
+		Removed:  removed,
+		Banks:    screened.Banks,
+		Hits:     screened.Hits,
+		NewHits:  screened.NewHits,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	})
+	return nil
+}
+
+func runScreen(a *app, args []string) error {
+	fs := flag.NewFlagSet("screen", flag.ContinueOnError)
+	all := fs.Bool("all", false, "screen every bank, not only new and changed ones")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("screen: unexpected arguments")
+	}
+
+	start := time.Now()
+	screened, err := screenBanks(a, *all)
+	if err != nil {
+		return err
+	}
+	a.out.screeningResult(screeningResult{Banks: screened.Banks, Hits: screened.Hits, NewHits: screened.NewHits, Duration: time.Since(start).Round(time.Millisecond).String()})
+	return nil
+}
+
+// screens banks against every imported sanctions list
+func screenBanks(a *app, all bool) (database.ScreeningResult, error) {
+	entries, err := database.ListSanctionEntriesContext(a.ctx, a.db)
+	if err != nil {
+		return database.ScreeningResult{}, err
+	}
+	if len(entries) == 0 {
+		return database.ScreeningResult{}, errors.New("no sanctions list imported, run import-sanctions first")
+	}
+	return database.ScreenBanksContext(a.ctx, a.db, screening.NewMatcher(entries, a.cfg.Screening.Threshold), all)
+}
+
+func runExport(a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", "csv", "csv, jsonl or json")
+	country := fs.String("country", "", "only this ISO2 country")
+	file := fs.String("file", "", "output file instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("export: unexpected arguments")
+	}
+
+	format, err := export.LookupFormat(*formatName)
+	if err != nil {
+		return usageError("export: " + err.Error())
+	}
+
+	var w io.Writer = a.out.w
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+
+	out := format.New(buf)
+	err = database.StreamBanks(a.ctx, a.db, *country, out.Write)
+	if err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+func runQuality(a *app, args []string) error {
+	fs := flag.NewFlagSet("quality", flag.ContinueOnError)
+	names := fs.String("checks", "", "comma separated checks, all by default")
+	samples := fs.Int("samples", quality.DefaultSamples, "violations listed per check")
+	fix := fs.Bool("fix", false, "store the corrections of the fixable checks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError("quality: unexpected arguments")
+	}
+	if *samples < 0 {
+		return usageError("quality: -samples must not be negative")
+	}
+
+	var list []string
+	if *names != "" {
+		list = strings.Split(*names, ",")
+	}
+	checks, err := quality.LookupChecks(list...)
+	if err != nil {
+		return usageError("quality: " + err.Error())
+	}
+
+	banks, err := database.AllBanksContext(a.ctx, a.db)
+	if err != nil {
+		return err
+	}
+	report := quality.Run(banks, checks, *samples)
+	if *fix {
+		fixed, err := database.FixBanksContext(a.ctx, a.db, quality.Fixes(banks, checks))
+		report.Fixed = len(fixed)
+		if err != nil {
+			a.out.qualityReport(report)
+			return err
+		}
+	}
+	a.out.qualityReport(report)
+
+	// like validate, violations that are left fail the command
+	for _, r := range report.Checks {
+		if r.Count > 0 && !(*fix && r.Fixable) {
+			return errSilent
+		}
+	}
+	return nil
+}
+
+func runValidate(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("validate: expected one CSV file")
+	}
+
+	report, err := parser.ValidateSwiftCSV(args[0])
+	if err != nil {
+		return err
+	}
+	a.out.report(report)
+	if len(report.Problems) > 0 {
+		return errSilent
+	}
+	return nil
+}
+
+func runDelete(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageError("delete: missing SWIFT code")
+	}
+
+	missing := false
+	for _, arg := range args {
+		code := normalizeCode(arg)
+		country, err := database.DeleteBankContext(a.ctx, a.db, code, time.Time{})
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintf(a.stderr, "%s: not found\n", code)
+			missing = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// keep Last-Modified of the country and headquarter responses correct
+		if err := database.TouchDeletedContext(a.ctx, a.db, code, country); err != nil {
+			return err
+		}
+		a.out.message("deleted " + code)
+	}
+
+	if missing {
+		return errSilent
+	}
+	return nil
+}
+
+func runMigrate(a *app, args []string) error {
+	if len(args) > 0 {
+		return usageError("migrate: unexpected arguments")
+	}
+	if err := config.Migrate(a.db); err != nil {
+		return err
+	}
+	a.out.message("schema is up to date")
+	return nil
+}
@@ -0,0 +1,190 @@
+// swiftctl works on the SWIFT codes database directly, it reads the same
+// configuration (config file, environment, .env) as the server.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	_ "time/tzdata" // time zones of imported files are checked against the embedded zoneinfo
+
+	"github.com/white67/swift_api/internal/config"
+	"github.com/white67/swift_api/internal/database"
+	"github.com/white67/swift_api/internal/geo"
+	"github.com/white67/swift_api/internal/logging"
+)
+
+const usage = `Usage: swiftctl [-config file] [-o table|json] [-v] <command> [arguments]
+
+Commands:
+  lookup <swift-code>...        show SWIFT codes, headquarters with their branches
+  country <ISO2>                list all SWIFT codes of a country
+  search [-country ISO2] [-limit n] <text>
+                                find codes by SWIFT code, bank name or address
+  import <csv>                  import a SWIFT codes CSV file, new codes are added and
+                                existing ones kept as they are
+  seed [csv]                    import the seed file, only into an empty database
+  import-identifiers <csv>      link national identifiers (scheme, identifier, SWIFT code) to banks
+  import-holidays [-country ISO2] <csv|ics>
+                                import (upsert) bank holidays, .ics files need -country
+  import-sanctions [-list name] <csv|xml>
+                                replace a sanctions list (CSV or EU consolidated list XML)
+                                and screen every bank against all lists
+  screen [-all]                 screen new and changed banks (-all: every bank) against the
+                                sanctions lists
+  export [-format csv|jsonl|json] [-country ISO2] [-file path]
+                                write the directory to stdout or a file
+  validate <csv>                check a CSV file without importing it
+  quality [-checks a,b] [-samples n] [-fix]
+                                run the data quality checks over the database,
+                                -fix stores the corrections of the fixable checks
+  delete <swift-code>...        delete SWIFT codes
+  migrate                       create missing tables and columns
+
+Changes are not pushed to a running server, its cache serves the old data for up
+to CACHE_TTL.
+`
+
+// errors that are already reported, only the exit code is left
+var errSilent = errors.New("")
+
+type command struct {
+	run   func(app *app, args []string) error
+	needs bool // needs a database connection
+}
+
+var commands = map[string]command{
+	"lookup":             {runLookup, true},
+	"country":            {runCountry, true},
+	"search":             {runSearch, true},
+	"import":             {runImport, true},
+	"seed":               {runSeed, true},
+	"import-identifiers": {runImportIdentifiers, true},
+	"import-holidays":    {runImportHolidays, true},
+	"import-sanctions":   {runImportSanctions, true},
+	"screen":             {runScreen, true},
+	"export":             {runExport, true},
+	"validate":           {runValidate, false},
+	"quality":            {runQuality, true},
+	"delete":             {runDelete, true},
+	"migrate":            {runMigrate, true},
+}
+
+type app struct {
+	ctx    context.Context
+	cfg    *config.Config
+	db     *sql.DB
+	out    *printer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("swiftctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	configFile := fs.String("config", "", "path to a YAML config file")
+	output := fs.String("o", "table", "output format, table or json")
+	verbose := fs.Bool("v", false, "log progress to stderr")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "invalid output format %q\n", *output)
+		return 2
+	}
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		if name != "" {
+			fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		}
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{ctx: ctx, out: &printer{w: stdout, json: *output == "json"}, stderr: stderr}
+
+	if cmd.needs {
+		level := "warn"
+		if *verbose {
+			level = "info"
+		}
+		if _, err := logging.Setup(stderr, level, "text"); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		var loadArgs []string
+		if *configFile != "" {
+			loadArgs = []string{"-config", *configFile}
+		}
+		cfg, err := config.Load(loadArgs)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid configuration: %v\n", err)
+			return 1
+		}
+		db, err := config.Connect(cfg.Database)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer db.Close()
+		a.cfg, a.db = cfg, db
+
+		// imports geocode towns like the server does
+		if cfg.GazetteerFile != "" {
+			gazetteer, err := geo.LoadGazetteer(cfg.GazetteerFile)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 1
+			}
+			database.SetGazetteer(gazetteer)
+		}
+	}
+
+	if err := cmd.run(a, fs.Args()[1:]); err != nil {
+		var usageErr usageError
+		switch {
+		case errors.As(err, &usageErr):
+			fmt.Fprintf(stderr, "%s\n\n", usageErr)
+			fs.Usage()
+			return 2
+		case errors.Is(err, errSilent):
+		default:
+			fmt.Fprintf(stderr, "swiftctl %s: %v\n", name, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// parses the flags of a subcommand, flags have to come before the arguments
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return usageError(fmt.Sprintf("%s: %v", fs.Name(), err))
+	}
+	return nil
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/white67/swift_api/internal/parser"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "swift_codes.csv")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestValidate(t *testing.T) {
+	path := writeCSV(t, `Country,SWIFT Code,Code Type,Bank Name,Address,Town,Country Name
+PL,TESTPLPWXXX,BIC11,Test Bank Poland,Test Address 1,Mielno,Poland
+PL,TESTPLPW123,BIC11,Test Bank Poland,Test Address 2,Mielno,Poland
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"validate", path}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "valid:           2")
+	assert.Contains(t, stdout.String(), "problems:        0")
+}
+
+func TestValidate_ProblemsJSON(t *testing.T) {
+	path := writeCSV(t, `Country,SWIFT Code,Code Type,Bank Name,Address,Town,Country Name
+PL,TESTPLPWXXX,BIC11,Test Bank Poland,Test Address 1,Mielno,Poland
+DE,TESTPLPW123,BIC11,Test Bank Poland,Test Address 2,Berlin,Germany
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-o", "json", "validate", path}, &stdout, &stderr)
+	assert.Equal(t, 1, code, "Problems should fail the command")
+
+	var report parser.Report
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, 1, report.Valid)
+	if assert.Len(t, report.Problems, 1) {
+		assert.Equal(t, 3, report.Problems[0].Line)
+		assert.Equal(t, "TESTPLPW123", report.Problems[0].SwiftCode)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Usage: swiftctl")
+
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"frobnicate"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "frobnicate"`)
+
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"validate"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "validate: expected one CSV file")
+
+	assert.Equal(t, 2, run([]string{"-o", "xml", "validate", "x.csv"}, &stdout, &stderr))
+}
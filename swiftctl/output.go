@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/white67/swift_api/internal/model"
+	"github.com/white67/swift_api/internal/parser"
+	"github.com/white67/swift_api/internal/quality"
+)
+
+// writes results as aligned tables or as indented JSON
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func (p *printer) encode(v any) {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func (p *printer) table(header string, rows func(tw *tabwriter.Writer)) {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	rows(tw)
+	tw.Flush()
+}
+
+func (p *printer) banks(banks []model.Bank) {
+	if p.json {
+		if banks == nil {
+			banks = []model.Bank{}
+		}
+		p.encode(banks)
+		return
+	}
+	p.table("SWIFT CODE\tHQ\tCOUNTRY\tNAME\tADDRESS", func(tw *tabwriter.Writer) {
+		for _, b := range banks {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b.SwiftCode, yesNo(b.IsHeadquarter), b.CountryCode, b.Name, b.Address)
+		}
+	})
+}
+
+func (p *printer) details(found []bankDetails) {
+	if p.json {
+		if found == nil {
+			found = []bankDetails{}
+		}
+		p.encode(found)
+		return
+	}
+	// branches are listed right below their headquarter
+	var rows []model.Bank
+	for _, d := range found {
+		rows = append(rows, d.Bank)
+		rows = append(rows, d.Branches...)
+	}
+	p.banks(rows)
+}
+
+func (p *printer) importResult(r importResult) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	switch {
+	case r.Skipped:
+		fmt.Fprintf(p.w, "database is not empty, %s was not imported\n", r.File)
+	case r.Failed > 0:
+		fmt.Fprintf(p.w, "imported %d of %d rows from %s in %s, %d failed (run with -v for details)\n", r.Rows-r.Failed, r.Rows, r.File, r.Duration, r.Failed)
+	default:
+		fmt.Fprintf(p.w, "imported %d rows from %s in %s\n", r.Rows, r.File, r.Duration)
+	}
+}
+
+func (p *printer) identifierImportResult(r identifierImportResult) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	linked := r.Rows - r.Unmatched - r.Failed
+	fmt.Fprintf(p.w, "linked %d of %d identifiers from %s in %s", linked, r.Rows, r.File, r.Duration)
+	if r.Unmatched > 0 {
+		fmt.Fprintf(p.w, ", %d with a SWIFT code not in the directory", r.Unmatched)
+	}
+	if r.Failed > 0 {
+		fmt.Fprintf(p.w, ", %d failed (run with -v for details)", r.Failed)
+	}
+	fmt.Fprintln(p.w)
+}
+
+func (p *printer) holidayImportResult(r holidayImportResult) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	if r.Country != "" {
+		fmt.Fprintf(p.w, "imported %d %s holidays from %s in %s\n", r.Holidays, r.Country, r.File, r.Duration)
+		return
+	}
+	fmt.Fprintf(p.w, "imported %d holidays from %s in %s\n", r.Holidays, r.File, r.Duration)
+}
+
+func (p *printer) screeningResult(r screeningResult) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	if r.File != "" {
+		fmt.Fprintf(p.w, "imported %d entries of list %s from %s", r.Entries, r.List, r.File)
+		if r.Removed > 0 {
+			fmt.Fprintf(p.w, ", %d no longer listed", r.Removed)
+		}
+		fmt.Fprintln(p.w)
+	}
+	fmt.Fprintf(p.w, "screened %d banks in %s: %d hits, %d new\n", r.Banks, r.Duration, r.Hits, r.NewHits)
+}
+
+func (p *printer) report(r *parser.Report) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	fmt.Fprintf(p.w, "rows:            %d\n", r.Rows)
+	fmt.Fprintf(p.w, "valid:           %d\n", r.Valid)
+	fmt.Fprintf(p.w, "headquarters:    %d\n", r.Headquarters)
+	fmt.Fprintf(p.w, "branches:        %d\n", r.Branches)
+	fmt.Fprintf(p.w, "countries:       %d\n", r.Countries)
+	fmt.Fprintf(p.w, "orphan branches: %d\n", len(r.OrphanBranches))
+	fmt.Fprintf(p.w, "problems:        %d\n", len(r.Problems))
+	if len(r.Problems) == 0 {
+		return
+	}
+	fmt.Fprintln(p.w)
+	p.table("LINE\tSWIFT CODE\tPROBLEM", func(tw *tabwriter.Writer) {
+		for _, pr := range r.Problems {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", pr.Line, pr.SwiftCode, pr.Message)
+		}
+	})
+}
+
+func (p *printer) qualityReport(r *quality.Report) {
+	if p.json {
+		p.encode(r)
+		return
+	}
+	fmt.Fprintf(p.w, "checked %d banks\n\n", r.Banks)
+	p.table("CHECK\tVIOLATIONS\tFIXABLE\tDESCRIPTION", func(tw *tabwriter.Writer) {
+		for _, c := range r.Checks {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", c.Check, c.Count, yesNo(c.Fixable), c.Description)
+		}
+	})
+	for _, c := range r.Checks {
+		if len(c.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(p.w, "\n%s:\n", c.Check)
+		p.table("SWIFT CODE\tPROBLEM", func(tw *tabwriter.Writer) {
+			for _, v := range c.Samples {
+				fmt.Fprintf(tw, "%s\t%s\n", v.SwiftCode, v.Message)
+			}
+		})
+	}
+	if r.Fixed > 0 {
+		fmt.Fprintf(p.w, "\nfixed %d banks\n", r.Fixed)
+	}
+}
+
+func (p *printer) message(msg string) {
+	if p.json {
+		p.encode(map[string]string{"message": msg})
+		return
+	}
+	fmt.Fprintln(p.w, msg)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}